@@ -0,0 +1,270 @@
+// Package agent implements a local key agent for sentencecipher.
+//
+// The agent holds named keys in memory and performs encode/decode operations on
+// behalf of clients, so keys never appear on the command line. Clients talk to
+// it over a Unix socket using one JSON request and one JSON response per
+// connection.
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+// Operations understood by the agent
+const (
+	OpAdd    = "add"
+	OpList   = "list"
+	OpRemove = "remove"
+	OpEncode = "encode"
+	OpDecode = "decode"
+)
+
+// Encoding modes for OpEncode and OpDecode
+const (
+	ModePlain    = "plain"
+	ModeNatural  = "natural"
+	ModeMarkdown = "markdown"
+	ModeFluent   = "fluent"
+)
+
+// DefaultIdleTimeout is how long an unused key stays in memory
+const DefaultIdleTimeout = 15 * time.Minute
+
+// Request is sent by a client
+type Request struct {
+	Op   string `json:"op"`
+	Name string `json:"name,omitempty"`
+	Key  string `json:"key,omitempty"`  // OpAdd only
+	Mode string `json:"mode,omitempty"` // OpEncode and OpDecode
+	Data []byte `json:"data,omitempty"` // OpEncode input
+	Text string `json:"text,omitempty"` // OpDecode input
+}
+
+// Response is returned by the agent
+type Response struct {
+	Error string   `json:"error,omitempty"`
+	Names []string `json:"names,omitempty"` // OpList
+	Data  []byte   `json:"data,omitempty"`  // OpDecode output
+	Text  string   `json:"text,omitempty"`  // OpEncode output
+}
+
+// DefaultSocketPath returns $SENTENCECIPHER_AGENT_SOCK, or a per-user socket in
+// $XDG_RUNTIME_DIR (falling back to the temp directory)
+func DefaultSocketPath() string {
+	if path := os.Getenv("SENTENCECIPHER_AGENT_SOCK"); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, fmt.Sprintf("sentencecipher-agent-%d.sock", os.Getuid()))
+}
+
+type entry struct {
+	cipher   *sentencecipher.Cipher
+	lastUsed time.Time
+}
+
+// Server holds keys in memory and answers client requests
+type Server struct {
+	IdleTimeout time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*entry
+	now  func() time.Time
+}
+
+// NewServer creates a Server that forgets keys unused for idleTimeout
+func NewServer(idleTimeout time.Duration) *Server {
+	return &Server{
+		IdleTimeout: idleTimeout,
+		keys:        make(map[string]*entry),
+		now:         time.Now,
+	}
+}
+
+// ListenAndServe listens on a Unix socket only the current user can access
+// and serves requests until the listener fails
+func (s *Server) ListenAndServe(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	if err := os.Chmod(path, 0600); err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l
+func (s *Server) Serve(l net.Listener) error {
+	done := make(chan struct{})
+	defer close(done)
+	go s.expireLoop(done)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// expireLoop periodically drops keys that have been idle too long
+func (s *Server) expireLoop(done <-chan struct{}) {
+	interval := s.IdleTimeout / 4
+	if interval <= 0 || interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.expire()
+		}
+	}
+}
+
+func (s *Server) expire() {
+	if s.IdleTimeout <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, e := range s.keys {
+		if s.now().Sub(e.lastUsed) > s.IdleTimeout {
+			delete(s.keys, name)
+		}
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: "invalid request: " + err.Error()})
+		return
+	}
+	json.NewEncoder(conn).Encode(s.Handle(req))
+}
+
+// Handle executes a single request
+func (s *Server) Handle(req Request) Response {
+	s.expire()
+
+	switch req.Op {
+	case OpAdd:
+		if req.Name == "" {
+			return Response{Error: "name is required"}
+		}
+		cipher, err := sentencecipher.NewCipher(req.Key)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		s.mu.Lock()
+		s.keys[req.Name] = &entry{cipher: cipher, lastUsed: s.now()}
+		s.mu.Unlock()
+		return Response{}
+
+	case OpList:
+		s.mu.Lock()
+		names := make([]string, 0, len(s.keys))
+		for name := range s.keys {
+			names = append(names, name)
+		}
+		s.mu.Unlock()
+		sort.Strings(names)
+		return Response{Names: names}
+
+	case OpRemove:
+		s.mu.Lock()
+		_, ok := s.keys[req.Name]
+		delete(s.keys, req.Name)
+		s.mu.Unlock()
+		if !ok {
+			return Response{Error: "unknown key: " + req.Name}
+		}
+		return Response{}
+
+	case OpEncode, OpDecode:
+		cipher, err := s.use(req.Name)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		if req.Op == OpEncode {
+			text, err := encode(cipher, req.Mode, req.Data)
+			if err != nil {
+				return Response{Error: err.Error()}
+			}
+			return Response{Text: text}
+		}
+		data, err := decode(cipher, req.Mode, req.Text)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Data: data}
+	}
+
+	return Response{Error: "unknown operation: " + req.Op}
+}
+
+// use looks up a key and refreshes its idle timer
+func (s *Server) use(name string) (*sentencecipher.Cipher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.keys[name]
+	if !ok {
+		return nil, errors.New("unknown key: " + name)
+	}
+	e.lastUsed = s.now()
+	return e.cipher, nil
+}
+
+func encode(cipher *sentencecipher.Cipher, mode string, data []byte) (string, error) {
+	switch mode {
+	case ModePlain, "":
+		return cipher.Encode(data)
+	case ModeNatural:
+		return cipher.EncodeNatural(data)
+	case ModeMarkdown:
+		return cipher.EncodeMarkdown(data)
+	case ModeFluent:
+		return cipher.EncodeFluent(data)
+	}
+	return "", errors.New("unknown mode: " + mode)
+}
+
+func decode(cipher *sentencecipher.Cipher, mode string, text string) ([]byte, error) {
+	switch mode {
+	case ModePlain, "":
+		return cipher.Decode(text)
+	case ModeNatural:
+		return cipher.DecodeNatural(text)
+	case ModeMarkdown:
+		return cipher.DecodeMarkdown(text)
+	case ModeFluent:
+		return cipher.DecodeFluent(text)
+	}
+	return nil, errors.New("unknown mode: " + mode)
+}
@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+func startAgent(t *testing.T, server *Server) *Client {
+	dir, err := os.MkdirTemp("", "scagent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "agent.sock")
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go server.Serve(l)
+	return NewClient(path)
+}
+
+func TestAgentEncodeDecode(t *testing.T) {
+	client := startAgent(t, NewServer(DefaultIdleTimeout))
+
+	if err := client.Add("team", "team-secret"); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+	if err := client.Add("ops", "ops-secret"); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+
+	names, err := client.List()
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"ops", "team"}) {
+		t.Errorf("List = %v, want [ops team]", names)
+	}
+
+	input := []byte("Keys stay inside the agent")
+	for _, mode := range []string{ModePlain, ModeNatural, ModeMarkdown, ModeFluent} {
+		text, err := client.Encode("team", mode, input)
+		if err != nil {
+			t.Fatalf("Encode(%s) error: %v", mode, err)
+		}
+		decoded, err := client.Decode("team", mode, text)
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", mode, err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("mode %s: decoded %q, want %q", mode, decoded, input)
+		}
+	}
+
+	// Text from the agent decodes with the same key outside it
+	text, _ := client.Encode("team", ModePlain, input)
+	cipher, _ := sentencecipher.NewCipher("team-secret")
+	if decoded, err := cipher.Decode(text); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("direct Decode = %q, %v", decoded, err)
+	}
+
+	if err := client.Remove("team"); err != nil {
+		t.Fatalf("Remove error: %v", err)
+	}
+	if _, err := client.Encode("team", ModePlain, input); err == nil {
+		t.Error("expected error after removing key")
+	}
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Now()
+	server := NewServer(time.Minute)
+	server.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	client := startAgent(t, server)
+
+	if err := client.Add("short", "short-lived"); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+
+	mu.Lock()
+	now = now.Add(2 * time.Minute)
+	mu.Unlock()
+	names, err := client.List()
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("idle key should have expired, got %v", names)
+	}
+}
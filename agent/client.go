@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Client talks to a running agent
+type Client struct {
+	SocketPath string
+	Timeout    time.Duration
+}
+
+// NewClient creates a Client for the agent listening on socketPath
+// (DefaultSocketPath when empty)
+func NewClient(socketPath string) *Client {
+	if socketPath == "" {
+		socketPath = DefaultSocketPath()
+	}
+	return &Client{SocketPath: socketPath, Timeout: 30 * time.Second}
+}
+
+// Add stores key in the agent under name
+func (c *Client) Add(name, key string) error {
+	_, err := c.call(Request{Op: OpAdd, Name: name, Key: key})
+	return err
+}
+
+// List returns the names of the keys held by the agent
+func (c *Client) List() ([]string, error) {
+	resp, err := c.call(Request{Op: OpList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Names, nil
+}
+
+// Remove deletes a key from the agent
+func (c *Client) Remove(name string) error {
+	_, err := c.call(Request{Op: OpRemove, Name: name})
+	return err
+}
+
+// Encode encodes data with the named key in the given mode
+func (c *Client) Encode(name, mode string, data []byte) (string, error) {
+	resp, err := c.call(Request{Op: OpEncode, Name: name, Mode: mode, Data: data})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// Decode decodes text with the named key in the given mode
+func (c *Client) Decode(name, mode string, text string) ([]byte, error) {
+	resp, err := c.call(Request{Op: OpDecode, Name: name, Mode: mode, Text: text})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data == nil {
+		return []byte{}, nil
+	}
+	return resp.Data, nil
+}
+
+func (c *Client) call(req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.SocketPath, c.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach agent at %s: %w", c.SocketPath, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.Timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
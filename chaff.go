@@ -0,0 +1,129 @@
+package sentencecipher
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+// Chaff sentences are full sentences (of a random structure) that carry no data. The
+// subject and verb are random; the indirect object and object are a keyed
+// tag of them:
+//
+//	IO, O = HMAC-SHA256(key, S || V)[0:2]
+//
+// Without the key a chaff sentence is indistinguishable from a data sentence,
+// so the sentence count no longer reveals the payload length. Data sentences
+// whose IO and O happen to match the tag get a different (ignored) IO.
+
+// SetChaffRatio mixes ratio chaff sentences per data sentence into the output
+// of Encode and EncodeNatural (0 disables chaff). Decode and DecodeNatural drop
+// chaff for any keyed cipher, whether or not a ratio is set.
+func (c *Cipher) SetChaffRatio(ratio float64) error {
+	if ratio < 0 {
+		return errors.New("chaff ratio must not be negative")
+	}
+	if ratio > 0 && c.key == "" {
+		return errors.New("chaff requires a key")
+	}
+	c.chaffRatio = ratio
+	return nil
+}
+
+// chaffTag returns the indirect-object and object indices that mark a chaff
+// sentence with subject s and verb v
+func (c *Cipher) chaffTag(s, v int) (io, o int) {
+	macKey := sha256.Sum256([]byte("sentence-cipher/chaff:" + c.key))
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write([]byte{byte(s), byte(v)})
+	sum := mac.Sum(nil)
+	return int(sum[0]), int(sum[1])
+}
+
+// isChaff reports whether the word indices of a full sentence carry the chaff tag
+func (c *Cipher) isChaff(s, v, io, o int) bool {
+	if c.key == "" {
+		return false
+	}
+	tagIO, tagO := c.chaffTag(s, v)
+	return io == tagIO && o == tagO
+}
+
+// isChaffSentence reports whether the words of a plain-mode sentence (without
+// the final punctuation) are chaff
+func (c *Cipher) isChaffSentence(words []string) bool {
+	if c.key == "" {
+		return false
+	}
+	s, v, io, o, ok := c.fullSentenceIndices(words)
+	return ok && c.isChaff(s, v, io, o)
+}
+
+// fullSentenceIndices looks up the word indices of a plain-mode full sentence
+func (c *Cipher) fullSentenceIndices(words []string) (s, v, io, o int, ok bool) {
+	p, ok := c.parseSentence(words)
+	if !ok || p.size != 3 {
+		return 0, 0, 0, 0, false
+	}
+	return p.s, p.v, p.io, p.o, true
+}
+
+// fullSentence renders a full sentence of a random structure from word
+// indices with words' lists
+func fullSentence(words *Cipher, s, v, io, o int) string {
+	structure := randomIntOr(len(words.patternSet().full), 0)
+	p := sentenceParse{s: s, v: v, io: io, o: o, ending: randomFiller(), size: 3, structure: structure}
+	return p.render(words)
+}
+
+// addChaff inserts chaff sentences (built from words' lists) at random
+// positions among sentences
+func (c *Cipher) addChaff(sentences []string, words *Cipher) []string {
+	if c.chaffRatio <= 0 || len(sentences) == 0 {
+		return sentences
+	}
+
+	// Round randomly so the expected count is exactly ratio * len(sentences)
+	want := c.chaffRatio * float64(len(sentences))
+	count := int(want)
+	if float64(randomIntOr(1<<16, 0))/(1<<16) < want-float64(count) {
+		count++
+	}
+
+	out := append([]string{}, sentences...)
+	for n := 0; n < count; n++ {
+		sv := randomIntOr(1<<16, n)
+		s, v := sv>>8, sv&0xFF
+		io, o := c.chaffTag(s, v)
+		chaff := fullSentence(words, s, v, io, o)
+
+		pos := randomIntOr(len(out)+1, len(out))
+		out = append(out, "")
+		copy(out[pos+1:], out[pos:])
+		out[pos] = chaff
+	}
+	return out
+}
+
+// randomInt returns a uniform random int in [0, n) from crypto/rand
+func randomInt(n int) (int, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint64(b[:]) % uint64(n)), nil
+}
+
+// randomIntOr is randomInt for the cover-text choices (indirect objects,
+// structures, endings, chaff, the envelope), which returns fallback if the
+// system random source fails: those choices only vary how the text reads, so
+// encoding goes on with a fixed one rather than failing.
+func randomIntOr(n, fallback int) int {
+	r, err := randomInt(n)
+	if err != nil {
+		return fallback
+	}
+	return r
+}
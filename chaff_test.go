@@ -0,0 +1,116 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestChaffRoundTrip(t *testing.T) {
+	cipher, _ := NewCipher("chaff-key")
+	if err := cipher.SetChaffRatio(1); err != nil {
+		t.Fatalf("SetChaffRatio error: %v", err)
+	}
+	input := []byte("Only the key holder can tell which sentences matter")
+
+	plain, err := cipher.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	receiver, _ := NewCipher("chaff-key")
+	decoded, err := receiver.Decode(plain)
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("decoded %q, want %q", decoded, input)
+	}
+
+	natural, err := cipher.EncodeNatural(input)
+	if err != nil {
+		t.Fatalf("EncodeNatural error: %v", err)
+	}
+	decoded, err = receiver.DecodeNatural(natural)
+	if err != nil {
+		t.Fatalf("DecodeNatural error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("natural decoded %q, want %q", decoded, input)
+	}
+}
+
+func TestChaffRatio(t *testing.T) {
+	cipher, _ := NewCipher("chaff-key")
+	input := bytes.Repeat([]byte("abcdefghij"), 3)
+
+	without, _ := cipher.Encode(input)
+	cipher.SetChaffRatio(2)
+	with, _ := cipher.Encode(input)
+
+	n, m := len(splitSentences(without)), len(splitSentences(with))
+	if m < 3*n-1 || m > 3*n+1 {
+		t.Errorf("ratio 2: %d sentences with chaff, %d without", m, n)
+	}
+
+	// The wrong key sees chaff as data and fails
+	other, _ := NewCipher("other-key")
+	if decoded, err := other.Decode(with); err == nil && bytes.Equal(decoded, input) {
+		t.Error("wrong key decoded chaffed message")
+	}
+}
+
+func TestChaffNotMistakenForData(t *testing.T) {
+	// Messages without chaff still decode for keyed ciphers, even when data
+	// sentences would collide with the chaff tag
+	cipher, _ := NewCipher("collision-key")
+	for i := 0; i < 200; i++ {
+		input := []byte{byte(i), byte(i * 7), byte(i * 13), byte(i * 31), byte(i * 61), byte(i * 97)}
+		raw := cipher.encodeRaw(input)
+		decoded, err := cipher.decodeRaw(raw)
+		if err != nil || !bytes.Equal(decoded, input) {
+			t.Fatalf("round trip of %v = %v, %v", input, decoded, err)
+		}
+	}
+}
+
+func TestSetChaffRatioValidation(t *testing.T) {
+	if err := NewDefaultCipher().SetChaffRatio(0.5); err == nil {
+		t.Error("expected error for chaff without a key")
+	}
+	cipher, _ := NewCipher("k")
+	if err := cipher.SetChaffRatio(-1); err == nil {
+		t.Error("expected error for negative ratio")
+	}
+}
+
+func TestDataSentencesAvoidChaffTag(t *testing.T) {
+	cipher, _ := NewCipher("chaff-key")
+	data := []byte("every encoder picks the indirect object through one place")
+
+	// Put the first sentence's object on its chaff tag, then ask for the
+	// tagged indirect object in every sentence
+	tagged := func(k int, p sentenceParse) int {
+		io, _ := cipher.chaffTag(p.s, p.v)
+		return io
+	}
+	for b := 0; b < 256; b++ {
+		data[2] = byte(b)
+		p := planSentences(data, cipher.patternSet().bits, cipher.patternSet().endingBits)[0].slots(0)
+		if _, o := cipher.chaffTag(p.s, p.v); p.o == o {
+			break
+		}
+	}
+
+	var sentences [][]string
+	for _, s := range cipher.encodeSentences(cipher, data, tagged) {
+		words := strings.Fields(strings.ToLower(trimSentenceEnd(s)))
+		if cipher.isChaffSentence(words) {
+			t.Errorf("data sentence carries the chaff tag: %s", s)
+		}
+		sentences = append(sentences, words)
+	}
+	decoded, err := cipher.decodeSentences(cipher, sentences)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("decodeSentences = %q, %v", decoded, err)
+	}
+}
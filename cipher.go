@@ -2,12 +2,13 @@ package sentencecipher
 
 import (
 	"bytes"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
-	"math/rand"
+	mathrand "math/rand"
 	"strconv"
 	"strings"
 	"unicode"
@@ -19,10 +20,18 @@ const Version = "2.1.0"
 
 // Cipher holds the shuffled word lists based on a key
 type Cipher struct {
-	names   []string
-	verbs   []string
-	objects []string
-	key     string // Store key for regenerating themed ciphers
+	names       []string
+	verbs       []string
+	objects     []string
+	key         string // Store key for regenerating themed ciphers
+	replay      ReplayCache
+	padding     Padding
+	chaffRatio  float64
+	randomized  bool
+	spoken      bool
+	patterns    *patternSet // nil for the built-in patterns
+	fluentModel int         // language model version for EncodeFluent, 0 for the latest
+	theme       string      // natural and Markdown theme, "" to pick one per message
 }
 
 // NewCipher creates a new Cipher with word lists shuffled based on the provided key
@@ -53,18 +62,15 @@ func NewDefaultCipher() *Cipher {
 
 // NewThemedCipher creates a Cipher based on a specific theme
 func NewThemedCipher(key string, theme string) *Cipher {
-	var verbs, objects []string
-
-	switch theme {
-	case "tech":
-		verbs = techVerbs
-		objects = techObjects
-	case "business":
-		verbs = defaultVerbs // default is business
-		objects = defaultObjects
-	default:
-		verbs = defaultVerbs
-		objects = defaultObjects
+	// Unknown themes fall back to business
+	rt, ok := lookupTheme(theme)
+	if !ok {
+		rt, _ = lookupTheme("business")
+	}
+	verbs, objects := rt.Verbs, rt.Objects
+	names := defaultNames
+	if len(rt.Names) > 0 {
+		names = rt.Names
 	}
 
 	if key != "" {
@@ -73,7 +79,7 @@ func NewThemedCipher(key string, theme string) *Cipher {
 		seed := int64(binary.BigEndian.Uint64(hash[:8]))
 
 		return &Cipher{
-			names:   shuffleWithSeed(defaultNames, seed),
+			names:   shuffleWithSeed(names, seed),
 			verbs:   shuffleWithSeed(verbs, seed+1),
 			objects: shuffleWithSeed(objects, seed+2),
 			key:     key,
@@ -82,7 +88,7 @@ func NewThemedCipher(key string, theme string) *Cipher {
 
 	// Default (unshuffled) but themed
 	return &Cipher{
-		names:   copySlice(defaultNames),
+		names:   copySlice(names),
 		verbs:   copySlice(verbs),
 		objects: copySlice(objects),
 		key:     "",
@@ -97,7 +103,7 @@ func copySlice(src []string) []string {
 
 func shuffleWithSeed(src []string, seed int64) []string {
 	dst := copySlice(src)
-	r := rand.New(rand.NewSource(seed))
+	r := mathrand.New(mathrand.NewSource(seed))
 	r.Shuffle(len(dst), func(i, j int) {
 		dst[i], dst[j] = dst[j], dst[i]
 	})
@@ -132,7 +138,8 @@ func decompress(data []byte) ([]byte, error) {
 // - Byte 1: Subject (name index 0-255)
 // - Byte 2: Verb (verb index 0-255)
 // - Byte 3: Object (object index 0-255)
-// IndirectObject is derived from (byte1 + byte2) % 256 for natural flow
+// IndirectObject carries no data for the decoder: it is a random name, so it can
+// double as a hidden channel (see EncodeDeniable) without looking any different
 
 // encodeRaw converts bytes to English sentences without compression (internal use)
 func (c *Cipher) encodeRaw(data []byte) string {
@@ -140,110 +147,134 @@ func (c *Cipher) encodeRaw(data []byte) string {
 		return ""
 	}
 
-	var sentences []string
-	i := 0
-
-	for i < len(data) {
-		remaining := len(data) - i
-
-		if remaining >= 3 {
-			// Full pattern: S + V + IO + O (encodes 3 bytes)
-			b1 := data[i]   // subject
-			b2 := data[i+1] // verb
-			b3 := data[i+2] // object
-
-			// Rotation logic with position offset
-			offset := i
-			idx1 := (int(b1) + offset) % 256
-			idx2 := (int(b2) + offset + 1) % 256
-			idx3 := (int(b3) + offset + 2) % 256
-
-			subject := c.names[idx1]
-			verb := c.verbs[idx2]
-			// IO derived for natural flow using rotated indices
-			ioIdx := (idx1 + idx2) % 256
-			indirectObj := c.names[ioIdx]
-			obj := c.objects[idx3]
-
-			sentences = append(sentences, fmt.Sprintf("%s %s %s %s.", subject, verb, indirectObj, obj))
-			i += 3
-		} else if remaining == 2 {
-			// Pattern: S + V + O (encodes 2 bytes)
-			b1 := data[i]
-			b2 := data[i+1]
-
-			offset := i
-			idx1 := (int(b1) + offset) % 256
-			idx2 := (int(b2) + offset + 1) % 256
-
-			subject := c.names[idx1]
-			verb := c.verbs[idx2]
-
-			sentences = append(sentences, fmt.Sprintf("%s %s daily.", subject, verb))
-			i += 2
-		} else {
-			// Pattern: S + works (encodes 1 byte)
-			b := data[i]
-			offset := i
-			idx := (int(b) + offset) % 256
+	if c.randomized {
+		// Falls back to the plain encoding if the system random source fails
+		if nonce, mixed, err := c.newNonce(data); err == nil {
+			nonceText := strings.Join(c.nonceSentences(nonce, c), " ")
+			return nonceText + " " + c.encodeRawWithIndirect(mixed, nil)
+		}
+	}
+	return c.encodeRawWithIndirect(data, nil)
+}
 
-			subject := c.names[idx]
+// encodeRawWithIndirect is encodeRaw with the indirect object word of the k-th
+// full sentence taken from indirect[k] (a random name when absent). Like the
+// random names, indirect[k] moves to another name where it would read as chaff
+// or a nonce sentence.
+func (c *Cipher) encodeRawWithIndirect(data []byte, indirect []string) string {
+	if len(data) == 0 {
+		return ""
+	}
 
-			sentences = append(sentences, fmt.Sprintf("%s works.", subject))
-			i++
-		}
+	fillers := make([]byte, len(data)/3)
+	if _, err := rand.Read(fillers); err != nil {
+		// Fall back to the classic derived indirect object
+		fillers = nil
 	}
 
+	sentences := c.encodeSentences(c, data, func(k int, p sentenceParse) int {
+		if k < len(indirect) {
+			return findIndex(c.names, indirect[k])
+		}
+		if k < len(fillers) {
+			return int(fillers[k])
+		}
+		// IO derived for natural flow using rotated indices
+		return (p.s + p.v) % 256
+	})
+	sentences = c.addChaff(sentences, c)
 	return strings.Join(sentences, " ")
 }
 
+// encodeSentences lays out data in sentences (see planSentences) written with
+// w's word lists. indirect picks the indirect object of the k-th full sentence
+// from its rotated slots, which moves to another name (see dataIndirect) where
+// the decoder would drop the sentence as chaff or a nonce.
+func (c *Cipher) encodeSentences(w *Cipher, data []byte, indirect func(k int, p sentenceParse) int) []string {
+	var sentences []string
+	pos, k := 0, 0
+	for _, plan := range planSentences(data, w.patternSet().bits, w.patternSet().endingBits) {
+		// Rotation by position prevents repeating words for the same bytes
+		p := plan.slots(pos)
+		if p.ending < 0 {
+			// An ending that carries no data is picked at random
+			p.ending = randomFiller()
+		}
+		if p.size == 3 {
+			p.io = c.dataIndirect(pos == 0, p.s, p.v, indirect(k, p), p.o)
+			k++
+		} else if n := len(w.patternSet().short[p.size]); n > 1 {
+			// Short sentences carry no structure bits: any pattern will do
+			p.structure = randomIntOr(n, 0)
+		}
+		sentences = append(sentences, p.render(w))
+		pos += p.size
+	}
+	return sentences
+}
+
 // decodeRaw converts English sentences back to bytes without decompression (internal use)
 func (c *Cipher) decodeRaw(encoded string) ([]byte, error) {
 	if encoded == "" {
 		return []byte{}, nil
 	}
 
-	sentences := splitSentences(encoded)
-	var result []byte
-	byteCount := 0 // Track byte position for rotation offset
-
-	for _, sentence := range sentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence == "" {
-			continue
-		}
-
-		words := strings.Fields(sentence)
-		if len(words) == 0 {
-			continue
+	var sentences [][]string
+	for _, sentence := range splitSentences(normalizeText(encoded)) {
+		words := strings.Fields(strings.ToLower(trimSentenceEnd(sentence)))
+		if len(words) > 0 {
+			sentences = append(sentences, words)
 		}
+	}
+	return c.decodeSentences(c, sentences)
+}
 
-		// Remove trailing punctuation from last word
-		lastIdx := len(words) - 1
-		words[lastIdx] = strings.TrimSuffix(words[lastIdx], ".")
+// decodeSentences decodes the lowercase words of each sentence with w's word
+// lists, dropping chaff and the nonce sentences of a randomized encoding
+func (c *Cipher) decodeSentences(w *Cipher, sentences [][]string) ([]byte, error) {
+	var plans []sentencePlan
+	pos := 0 // byte position for the rotation offset
+	var nonce []byte
+	first := true
 
-		// Get rotated bytes from decodeSentence (which returns indices basically)
-		chunkBytes, err := c.decodeSentence(words)
-		if err != nil {
-			return nil, err
+	for _, words := range sentences {
+		p, ok := w.parseSentence(words)
+		if !ok {
+			return nil, w.sentenceError(words)
 		}
-
-		// Un-rotate bytes using position offset
-		for j, bRotated := range chunkBytes {
-			offset := byteCount + j
-			// Reverse rotation: val = (rotated - offset) % 256
-			val := (int(bRotated) - offset) % 256
-			if val < 0 {
-				val += 256
+		if p.size == 3 {
+			if c.isChaff(p.s, p.v, p.io, p.o) {
+				continue
+			}
+			// The nonce sentences come before any data, in order
+			if j := len(nonce) / 2; first && j < nonceSentenceCount && c.isNonce(j, p.s, p.v, p.io, p.o) {
+				nonce = append(nonce, byte(p.s), byte(p.v))
+				continue
 			}
-			result = append(result, byte(val))
 		}
-		byteCount += len(chunkBytes)
+		first = false
+		plans = append(plans, p.plan(pos))
+		pos += p.size
 	}
 
+	result, err := joinPlans(plans, w.patternSet().bits, w.patternSet().endingBits)
+	if err != nil {
+		return nil, err
+	}
+	if nonce != nil {
+		if len(nonce) < nonceSize {
+			return nil, errors.New("randomized text is missing nonce sentences")
+		}
+		c.shiftNonceKeystream(nonce, result, -1)
+	}
 	return result, nil
 }
 
+// trimSentenceEnd trims the space and final period or question mark of a sentence
+func trimSentenceEnd(sentence string) string {
+	return strings.TrimRight(strings.TrimSpace(sentence), ".?")
+}
+
 // Encode compresses data with flate then converts to English sentences
 func (c *Cipher) Encode(data []byte) (string, error) {
 	if len(data) == 0 {
@@ -253,23 +284,27 @@ func (c *Cipher) Encode(data []byte) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("compression failed: %w", err)
 	}
-	return c.encodeRaw(compressed), nil
+	padded, err := c.padPayload(compressed)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeRaw(padded), nil
 }
 
-// Decode converts English sentences back to bytes then decompresses
+// Decode converts English sentences back to bytes then decompresses.
+// A message hidden for this key by EncodeDeniable takes precedence.
 func (c *Cipher) Decode(encoded string) ([]byte, error) {
 	if encoded == "" {
 		return []byte{}, nil
 	}
+	if hidden, ok := c.decodeHidden(encoded); ok {
+		return hidden, nil
+	}
 	compressed, err := c.decodeRaw(encoded)
 	if err != nil {
 		return nil, err
 	}
-	decompressed, err := decompress(compressed)
-	if err != nil {
-		return nil, fmt.Errorf("decompression failed: %w", err)
-	}
-	return decompressed, nil
+	return c.openPayload(compressed)
 }
 
 // Encode compresses then encodes (package-level)
@@ -288,7 +323,7 @@ func splitSentences(text string) []string {
 
 	for _, r := range text {
 		current.WriteRune(r)
-		if r == '.' {
+		if r == '.' || r == '?' {
 			sentences = append(sentences, current.String())
 			current.Reset()
 		}
@@ -309,51 +344,11 @@ func (c *Cipher) decodeSentence(words []string) ([]byte, error) {
 	}
 	words = cleanWords
 
-	if len(words) < 2 {
-		return nil, errors.New("invalid sentence: too few words")
-	}
-
-	// Pattern: "subject works" (1 byte)
-	if len(words) == 2 && words[1] == "works" {
-		sIdx := findIndex(c.names, words[0])
-		if sIdx == -1 {
-			return nil, errors.New("unknown name: " + words[0])
-		}
-		return []byte{byte(sIdx)}, nil
-	}
-
-	// Pattern: "subject verb daily" (2 bytes)
-	if len(words) == 3 && words[2] == "daily" {
-		sIdx := findIndex(c.names, words[0])
-		if sIdx == -1 {
-			return nil, errors.New("unknown name: " + words[0])
-		}
-		vIdx := findIndex(c.verbs, words[1])
-		if vIdx == -1 {
-			return nil, errors.New("unknown verb: " + words[1])
-		}
-		return []byte{byte(sIdx), byte(vIdx)}, nil
+	p, ok := c.parseSentence(words)
+	if !ok {
+		return nil, c.sentenceError(words)
 	}
-
-	// Pattern: "subject verb indirectObject object" (3 bytes)
-	if len(words) == 4 {
-		sIdx := findIndex(c.names, words[0])
-		if sIdx == -1 {
-			return nil, errors.New("unknown name: " + words[0])
-		}
-		vIdx := findIndex(c.verbs, words[1])
-		if vIdx == -1 {
-			return nil, errors.New("unknown verb: " + words[1])
-		}
-		// IO is derived, we don't decode it
-		oIdx := findIndex(c.objects, words[3])
-		if oIdx == -1 {
-			return nil, errors.New("unknown object: " + words[3])
-		}
-		return []byte{byte(sIdx), byte(vIdx), byte(oIdx)}, nil
-	}
-
-	return nil, errors.New("unrecognized sentence pattern: " + strings.Join(words, " "))
+	return []byte{byte(p.s), byte(p.v), byte(p.o)}[:p.size], nil
 }
 
 // decodeSentence for backward compatibility (uses default word lists)
@@ -398,14 +393,9 @@ func DecodeString(encoded string) (string, error) {
 	return string(data), nil
 }
 
-// encodeNaturalRaw creates natural-looking sentences without compression (internal use)
-func (c *Cipher) encodeNaturalRaw(data []byte) string {
-	if len(data) == 0 {
-		return ""
-	}
-
-	// Calculate a simple seed from data to make deterministic choices
-	// Mix in key hash so that different keys produce different seeds/themes
+// coverSeed calculates a simple seed from data to make deterministic choices.
+// The key hash is mixed in so that different keys produce different seeds/themes.
+func (c *Cipher) coverSeed(data []byte) int {
 	seed := 0
 	if c.key != "" {
 		hash := sha256.Sum256([]byte(c.key))
@@ -416,85 +406,94 @@ func (c *Cipher) encodeNaturalRaw(data []byte) string {
 	for _, b := range data {
 		seed = (seed + int(b)) % 10000
 	}
+	return seed
+}
 
-	// Determine Theme
-	// 50% chance for Tech, 50% for Business
-	theme := "business"
+// themeForSeed picks the cover theme: 50% chance for Tech, 50% for Business
+func themeForSeed(seed int) string {
 	if seed%2 == 0 {
-		theme = "tech"
+		return "tech"
 	}
+	return "business"
+}
 
-	// Select Subject based on Theme
-	var subj string
-	if theme == "tech" {
-		subj = techSubjects[seed%len(techSubjects)]
-	} else {
-		subj = businessSubjects[seed%len(businessSubjects)]
+// themeForSubject detects the theme from a subject or title line (defaults to business)
+func themeForSubject(subj string) string {
+	for _, name := range ThemeNames() {
+		rt, _ := lookupTheme(name)
+		for _, s := range rt.Subjects {
+			if strings.EqualFold(s, subj) {
+				return name
+			}
+		}
 	}
+	return "business"
+}
 
-	// Create Themed Cipher to encode the body
-	// This ensures the words match the theme
-	themedCipher := NewThemedCipher(c.key, theme)
+// trimSubjectCounter removes a trailing thread counter such as "(3/12)" from a subject
+func trimSubjectCounter(subj string) string {
+	subj = strings.TrimSpace(subj)
+	if !strings.HasSuffix(subj, ")") {
+		return subj
+	}
+	open := strings.LastIndex(subj, "(")
+	if open == -1 {
+		return subj
+	}
+	counter := strings.SplitN(subj[open+1:len(subj)-1], "/", 2)
+	if len(counter) != 2 || !isDigits(counter[0]) || !isDigits(counter[1]) {
+		return subj
+	}
+	return strings.TrimSpace(subj[:open])
+}
 
-	// Generate basic sentences first using the themed cipher
-	var sentences []string
-	i := 0
-	for i < len(data) {
-		remaining := len(data) - i
-		var s string
-
-		if remaining >= 3 {
-			b1 := data[i]
-			b2 := data[i+1]
-			b3 := data[i+2]
-
-			// Rotation logic to prevent repeating words for same bytes
-			// We use position (i) to offset the index
-			offset := i
-			idx1 := (int(b1) + offset) % 256
-			idx2 := (int(b2) + offset + 1) % 256
-			idx3 := (int(b3) + offset + 2) % 256
-
-			subject := themedCipher.names[idx1]
-			verb := themedCipher.verbs[idx2]
-			ioIdx := (idx1 + idx2) % 256
-			indirectObj := themedCipher.names[ioIdx]
-			obj := themedCipher.objects[idx3]
-			s = fmt.Sprintf("%s %s %s %s", subject, verb, indirectObj, obj)
-			i += 3
-		} else if remaining == 2 {
-			b1 := data[i]
-			b2 := data[i+1]
-
-			offset := i
-			idx1 := (int(b1) + offset) % 256
-			idx2 := (int(b2) + offset + 1) % 256
-
-			subject := themedCipher.names[idx1]
-			verb := themedCipher.verbs[idx2]
-			s = fmt.Sprintf("%s %s daily", subject, verb)
-			i += 2
-		} else {
-			b := data[i]
+// encodeNaturalRaw creates natural-looking sentences without compression (internal use)
+func (c *Cipher) encodeNaturalRaw(data []byte) string {
+	return c.encodeNaturalEmail(data, "")
+}
 
-			offset := i
-			idx := (int(b) + offset) % 256
+// encodeNaturalEmail builds the email; subjectSuffix is appended to the subject line
+// (e.g. " (3/12)" for split messages)
+func (c *Cipher) encodeNaturalEmail(data []byte, subjectSuffix string) string {
+	if len(data) == 0 {
+		return ""
+	}
 
-			subject := themedCipher.names[idx]
-			s = fmt.Sprintf("%s works", subject)
-			i++
+	var nonce []byte
+	if c.randomized {
+		// Falls back to the plain encoding if the system random source fails
+		if n, mixed, err := c.newNonce(data); err == nil {
+			nonce, data = n, mixed
 		}
-		sentences = append(sentences, s)
 	}
 
+	seed := c.envelopeSeed(data)
+	theme := c.coverTheme(seed)
+
+	// Select Subject, Opener and Closer based on Theme
+	subj, opener, closer := themeEnvelope(theme, seed)
+
+	// Create Themed Cipher to encode the body
+	// This ensures the words match the theme
+	themedCipher := c.themed(theme)
+
+	// Generate basic sentences first using the themed cipher. The IO parity
+	// repeats the theme so it survives a rewritten subject.
+	sentences := c.encodeSentences(themedCipher, data, func(k int, p sentenceParse) int {
+		return c.themeParity(theme, k, (p.s+p.v)%256)
+	})
+	if nonce != nil {
+		sentences = append(c.nonceSentences(nonce, themedCipher), sentences...)
+	}
+	sentences = c.addChaff(sentences, themedCipher)
+
 	// Construct Email
 	var sb strings.Builder
 
 	// Subject Line
-	sb.WriteString("Subject: " + subj + "\n\n")
+	sb.WriteString("Subject: " + subj + subjectSuffix + "\n\n")
 
 	// Opener
-	opener := emailOpeners[seed%len(emailOpeners)]
 	sb.WriteString(opener + "\n\n")
 
 	// Body
@@ -502,8 +501,9 @@ func (c *Cipher) encodeNaturalRaw(data []byte) string {
 		// Capitalize first letter of the sentence logic happens after connector logic
 		prefix := ""
 
-		// Add connector mostly for subsequent sentences, not the first one
-		if idx > 0 {
+		// Add connector mostly for subsequent statements, not the first one, and
+		// only before a name: a literal start ("the", "can") would read wrong
+		if idx > 0 && findIndex(themedCipher.names, strings.Fields(s)[0]) != -1 {
 			// Use deterministic pseudo-randomness based on index + seed
 			r := (seed + idx) % 10
 			if r < 6 { // 60% chance to add a connector
@@ -514,7 +514,7 @@ func (c *Cipher) encodeNaturalRaw(data []byte) string {
 
 		// Capitalize sentence
 		s = capitalize(s)
-		sb.WriteString(prefix + s + ".")
+		sb.WriteString(prefix + s)
 
 		// Paragraph spacing
 		if (idx+1)%3 == 0 && idx < len(sentences)-1 {
@@ -526,7 +526,6 @@ func (c *Cipher) encodeNaturalRaw(data []byte) string {
 
 	// Closer
 	sb.WriteString("\n\n")
-	closer := emailClosers[seed%len(emailClosers)]
 	sb.WriteString(closer + "\n")
 
 	// Random Sender (use one of the names based on seed)
@@ -539,36 +538,32 @@ func (c *Cipher) encodeNaturalRaw(data []byte) string {
 
 // decodeNaturalRaw decodes natural email without decompression (internal use)
 func (c *Cipher) decodeNaturalRaw(encoded string) ([]byte, error) {
+	data, _, err := c.decodeNaturalTheme(encoded)
+	return data, err
+}
+
+// decodeNaturalTheme is decodeNaturalRaw that also reports the detected theme
+func (c *Cipher) decodeNaturalTheme(encoded string) ([]byte, ThemeMatch, error) {
 	if encoded == "" {
-		return []byte{}, nil
+		return []byte{}, ThemeMatch{}, nil
 	}
 
-	lines := strings.Split(encoded, "\n")
+	lines := reflowEmailLines(strings.Split(normalizeText(encoded), "\n"))
 	var bodyLines []string
 
-	// Detect Theme from Subject
-	theme := "business" // default
+	// Theme from Subject, one hint among others for detectTheme
+	theme := ""
 
 	// Scan for Subject first
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if strings.HasPrefix(strings.ToLower(line), "subject:") {
 			subj := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(line), "subject:"))
-
-			// Check if subject belongs to Tech
-			for _, s := range techSubjects {
-				if strings.EqualFold(s, subj) {
-					theme = "tech"
-					break
-				}
-			}
+			theme = themeForSubject(trimSubjectCounter(subj))
 			break
 		}
 	}
 
-	// Use Themed Cipher for decoding
-	themedCipher := NewThemedCipher(c.key, theme)
-
 	// Filter structure
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -581,35 +576,16 @@ func (c *Cipher) decodeNaturalRaw(encoded string) ([]byte, error) {
 			continue
 		}
 
-		// Skip Openers
-		isOpener := false
-		for _, op := range emailOpeners {
-			if strings.TrimSpace(op) == line {
-				isOpener = true
-				break
-			}
-		}
-		if isOpener {
-			continue
-		}
-
-		// Skip Closers
-		isCloser := false
-		for _, cl := range emailClosers {
-			if strings.TrimSpace(cl) == line {
-				isCloser = true
-				break
-			}
-		}
-		if isCloser {
+		// Skip Openers and Closers
+		if isEmailOpener(line) || isEmailCloser(line) {
 			continue
 		}
 
 		// Check if it's just a name (Sender signature)
 		// This is tricky because a name could be a valid 1-byte sentence "Name works." but here signature is just "Name"
 		// Signature usually doesn't end with "."
-		// Our sentences always end with "."
-		if !strings.HasSuffix(line, ".") {
+		// Our sentences always end with "." or "?"
+		if !strings.HasSuffix(line, ".") && !strings.HasSuffix(line, "?") {
 			// Likely a signature or subject garbage
 			continue
 		}
@@ -623,111 +599,31 @@ func (c *Cipher) decodeNaturalRaw(encoded string) ([]byte, error) {
 	// Note: Connectors usually end with comma, sentences end with period.
 	rawSentences := splitSentences(fullBody)
 
-	var result []byte
-	byteCount := 0 // Track byte position for rotation offset
-
-	for _, sentence := range rawSentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence == "" {
-			continue
-		}
-
-		// Remove connectors
-		for _, conn := range sentenceConnectors {
-			// Connector usually has space after it
-			// Check case-insensitive matching for connectors?
-			// In Encode, we use them as is (Title case).
-			if strings.HasPrefix(sentence, conn) {
-				sentence = strings.TrimPrefix(sentence, conn)
-				sentence = strings.TrimSpace(sentence)
-				break
-			}
-		}
-
-		// Clean up punctuation just in case
-		sentence = strings.TrimSuffix(sentence, ".")
-
-		words := strings.Fields(sentence)
-		if len(words) == 0 {
-			continue
-		}
-
-		// Lowercase everything for decoding
-		for i := range words {
-			words[i] = strings.ToLower(words[i])
-		}
-
-		// Decode using the new logic that respects offset
-		// We use themedCipher instead of 'c' to lookup verbs/objects
-
-		// Inline logic for rotation awareness:
-		if len(words) < 2 {
-			return nil, fmt.Errorf("invalid sentence: too few words: %s", sentence)
+	// Try the themes from most to least likely; the first whose vocabulary
+	// decodes the whole body wins
+	ranked := c.rankThemes(rawSentences, theme)
+	var firstErr error
+	for _, candidate := range ranked.scores {
+		result, err := c.decodeNaturalBody(c.themed(candidate.name), rawSentences)
+		if err == nil {
+			return result, ranked.match(candidate.name), nil
 		}
-
-		// Pattern: "subject works" (1 byte)
-		if len(words) == 2 && words[1] == "works" {
-			sIdx := findIndex(themedCipher.names, words[0])
-			if sIdx == -1 {
-				return nil, fmt.Errorf("unknown name: %s", words[0])
-			}
-
-			offset := byteCount
-			b := (sIdx - offset + 256) % 256
-			result = append(result, byte(b))
-			byteCount++
-			continue
+		if firstErr == nil {
+			firstErr = err
 		}
+	}
+	return nil, ThemeMatch{}, firstErr
+}
 
-		// Pattern: "subject verb daily" (2 bytes)
-		if len(words) == 3 && words[2] == "daily" {
-			sIdx := findIndex(themedCipher.names, words[0])
-			if sIdx == -1 {
-				return nil, fmt.Errorf("unknown name: %s", words[0])
-			}
-			vIdx := findIndex(themedCipher.verbs, words[1])
-			if vIdx == -1 {
-				return nil, fmt.Errorf("unknown verb: %s", words[1])
-			}
-
-			offset := byteCount
-			b1 := (sIdx - offset + 256) % 256
-			b2 := (vIdx - offset - 1 + 256) % 256
-
-			result = append(result, byte(b1), byte(b2))
-			byteCount += 2
-			continue
-		}
-
-		// Pattern: "subject verb indirectObject object" (3 bytes)
-		if len(words) == 4 {
-			sIdx := findIndex(themedCipher.names, words[0])
-			if sIdx == -1 {
-				return nil, fmt.Errorf("unknown name: %s", words[0])
-			}
-			vIdx := findIndex(themedCipher.verbs, words[1])
-			if vIdx == -1 {
-				return nil, fmt.Errorf("unknown verb: %s", words[1])
-			}
-			oIdx := findIndex(themedCipher.objects, words[3])
-			if oIdx == -1 {
-				return nil, fmt.Errorf("unknown object: %s", words[3])
-			}
-
-			offset := byteCount
-			b1 := (sIdx - offset + 256) % 256
-			b2 := (vIdx - offset - 1 + 256) % 256
-			b3 := (oIdx - offset - 2 + 256) % 256
-
-			result = append(result, byte(b1), byte(b2), byte(b3))
-			byteCount += 3
-			continue
+// decodeNaturalBody decodes the body sentences of a natural email with a themed cipher
+func (c *Cipher) decodeNaturalBody(themedCipher *Cipher, rawSentences []string) ([]byte, error) {
+	var sentences [][]string
+	for _, sentence := range rawSentences {
+		if words := naturalWords(sentence); len(words) > 0 {
+			sentences = append(sentences, words)
 		}
-
-		return nil, fmt.Errorf("unrecognized sentence pattern: %s", sentence)
 	}
-
-	return result, nil
+	return c.decodeSentences(themedCipher, sentences)
 }
 
 // EncodeNatural compresses data then creates natural-looking email sentences
@@ -739,7 +635,11 @@ func (c *Cipher) EncodeNatural(data []byte) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("compression failed: %w", err)
 	}
-	return c.encodeNaturalRaw(compressed), nil
+	padded, err := c.padPayload(compressed)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeNaturalRaw(padded), nil
 }
 
 // DecodeNatural decodes natural email then decompresses
@@ -751,11 +651,7 @@ func (c *Cipher) DecodeNatural(encoded string) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	decompressed, err := decompress(compressed)
-	if err != nil {
-		return nil, fmt.Errorf("decompression failed: %w", err)
-	}
-	return decompressed, nil
+	return c.openPayload(compressed)
 }
 
 // EncodeNatural compresses then encodes as natural (package-level)
@@ -768,6 +664,30 @@ func DecodeNatural(encoded string) ([]byte, error) {
 	return NewDefaultCipher().DecodeNatural(encoded)
 }
 
+// naturalWords strips the connector and final punctuation from a natural-mode
+// sentence and returns its lowercase words
+func naturalWords(sentence string) []string {
+	sentence = strings.TrimSpace(sentence)
+
+	// Remove connectors (any case: forwarding or retyping may change it)
+	for _, conn := range sentenceConnectors {
+		if len(sentence) >= len(conn) && strings.EqualFold(sentence[:len(conn)], conn) {
+			sentence = strings.TrimSpace(sentence[len(conn):])
+			break
+		}
+	}
+
+	// Clean up punctuation just in case
+	sentence = trimSentenceEnd(sentence)
+
+	// Lowercase everything for decoding
+	words := strings.Fields(sentence)
+	for i := range words {
+		words[i] = strings.ToLower(words[i])
+	}
+	return words
+}
+
 // Helper to capitalize first letter
 func capitalize(s string) string {
 	if len(s) == 0 {
@@ -123,9 +123,9 @@ func TestEncodedLooksLikeEnglish(t *testing.T) {
 		t.Error("Encoded text should not be empty")
 	}
 
-	// Should end with a period
-	if encoded[len(encoded)-1] != '.' {
-		t.Error("Encoded text should end with a period")
+	// Should end with a period or a question mark
+	if end := encoded[len(encoded)-1]; end != '.' && end != '?' {
+		t.Error("Encoded text should end with a period or a question mark")
 	}
 
 	// Should contain spaces (word separation)
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kittizz/sentence-cipher/agent"
+	"golang.org/x/term"
+)
+
+// runAgent implements "agent": run the key agent, or manage its keys with
+// "agent add NAME", "agent list" and "agent remove NAME"
+func runAgent(args []string) int {
+	fs := flag.NewFlagSet("agent", flag.ExitOnError)
+	socketFlag := fs.String("socket", agent.DefaultSocketPath(), "Agent socket path (env SENTENCECIPHER_AGENT_SOCK)")
+	timeoutFlag := fs.Duration("timeout", agent.DefaultIdleTimeout, "Forget keys unused for this long")
+	fs.Parse(args)
+
+	client := agent.NewClient(*socketFlag)
+
+	switch fs.Arg(0) {
+	case "":
+		server := agent.NewServer(*timeoutFlag)
+		fmt.Fprintf(os.Stderr, "Agent listening on %s\n", *socketFlag)
+		if err := server.ListenAndServe(*socketFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error running agent: %v\n", err)
+			return 1
+		}
+		return 0
+
+	case "add":
+		name := fs.Arg(1)
+		if name == "" {
+			fmt.Fprintln(os.Stderr, "Error: agent add NAME")
+			return 1
+		}
+		key, err := readPassphrase(fmt.Sprintf("Passphrase for %s: ", name))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading passphrase: %v\n", err)
+			return 1
+		}
+		if err := client.Add(name, key); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Added %s\n", name)
+		return 0
+
+	case "list":
+		names, err := client.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return 0
+
+	case "remove":
+		if err := client.Remove(fs.Arg(1)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: unknown agent command %q\n", fs.Arg(0))
+	return 1
+}
+
+// stdinLines reads piped passphrases; it is shared so a second prompt gets the
+// next line rather than whatever the first read left buffered
+var stdinLines = bufio.NewReader(os.Stdin)
+
+// readPassphrase prompts without echo on a terminal, or reads one line from a pipe
+func readPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, prompt)
+		pass, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		if len(pass) == 0 {
+			return "", errors.New("empty passphrase")
+		}
+		return string(pass), nil
+	}
+
+	line, err := stdinLines.ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		if err != nil {
+			return "", err
+		}
+		return "", errors.New("empty passphrase")
+	}
+	return line, nil
+}
+
+// agentMode maps the encoding flags to an agent mode
+func agentMode(natural, markdown, fluent bool) string {
+	switch {
+	case fluent:
+		return agent.ModeFluent
+	case markdown:
+		return agent.ModeMarkdown
+	case natural:
+		return agent.ModeNatural
+	}
+	return agent.ModePlain
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+// runKeyring implements "keyring": manage a passphrase-encrypted keyring file
+// with "keyring add NAME" (which prompts for the key), "keyring list" and
+// "keyring remove NAME", and encode or decode with it through "keyring encode
+// NAME" and "keyring decode"
+func runKeyring(args []string) int {
+	fs := flag.NewFlagSet("keyring", flag.ExitOnError)
+	fileFlag := fs.String("f", "team.keyring", "Keyring file")
+	naturalFlag := fs.Bool("n", false, "With encode and decode: use natural encoding")
+	inputFile := fs.String("i", "", "With encode and decode: input file (default: the arguments)")
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: grammarcipher keyring add|list|remove|encode|decode [-f FILE] ...")
+		return 1
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	passphrase, err := readPassphrase(fmt.Sprintf("Passphrase for %s: ", *fileFlag))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading passphrase: %v\n", err)
+		return 1
+	}
+	kr, err := sentencecipher.LoadKeyring(*fileFlag, passphrase)
+	if errors.Is(err, os.ErrNotExist) && command == "add" {
+		kr, err = sentencecipher.NewKeyring(), nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening keyring: %v\n", err)
+		return 1
+	}
+
+	switch command {
+	case "add":
+		if fs.Arg(0) == "" {
+			fmt.Fprintln(os.Stderr, "Error: keyring add NAME")
+			return 1
+		}
+		// Read like the passphrase, so the key stays out of shell history and ps
+		key, err := readPassphrase(fmt.Sprintf("Key to store as %s: ", fs.Arg(0)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading key: %v\n", err)
+			return 1
+		}
+		if err := kr.Add(fs.Arg(0), key); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		return saveKeyring(kr, *fileFlag, passphrase)
+
+	case "list":
+		for _, name := range kr.Names() {
+			fmt.Println(name)
+		}
+		return 0
+
+	case "remove":
+		kr.Remove(fs.Arg(0))
+		return saveKeyring(kr, *fileFlag, passphrase)
+
+	case "encode":
+		input, err := keyringInput(*inputFile, fs.Args()[1:])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		var text string
+		if *naturalFlag {
+			text, err = kr.EncodeNatural(fs.Arg(0), []byte(input))
+		} else {
+			text, err = kr.Encode(fs.Arg(0), []byte(input))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding: %v\n", err)
+			return 1
+		}
+		fmt.Println(text)
+		return 0
+
+	case "decode":
+		input, err := keyringInput(*inputFile, fs.Args())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		var data []byte
+		var name string
+		if *naturalFlag {
+			data, name, err = kr.DecodeNatural(strings.TrimSpace(input))
+		} else {
+			data, name, err = kr.Decode(strings.TrimSpace(input))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error decoding: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Decoded with %s\n", name)
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: unknown keyring command %q\n", command)
+	return 1
+}
+
+// keyringInput reads the message from file, or joins args when file is empty.
+// Stdin is left alone: it already supplied the keyring passphrase.
+func keyringInput(file string, args []string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		return string(data), err
+	}
+	if len(args) == 0 {
+		return "", errors.New("no input provided (use -i FILE or arguments)")
+	}
+	return strings.Join(args, " "), nil
+}
+
+// saveKeyring writes kr back to path under passphrase
+func saveKeyring(kr *sentencecipher.Keyring, path, passphrase string) int {
+	if err := kr.Save(path, passphrase); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing keyring: %v\n", err)
+		return 1
+	}
+	return 0
+}
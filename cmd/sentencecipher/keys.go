@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+// runKeygen implements "keygen": write NAME.pub and NAME.key
+func runKeygen(args []string) int {
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	outputName := fs.String("o", "", "Write NAME.pub (share it) and NAME.key (keep it private)")
+	signFlag := fs.Bool("sign", false, "Create an Ed25519 signing key pair (NAME.signing.key, NAME.signing.pub)")
+	fs.Parse(args)
+
+	if *outputName == "" {
+		fmt.Fprintln(os.Stderr, "Error: -o NAME is required")
+		return 1
+	}
+
+	if *signFlag {
+		vk, sk, err := sentencecipher.GenerateSigningKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating key pair: %v\n", err)
+			return 1
+		}
+		return writeKeyPair(*outputName+".signing", vk.String(), sk.String())
+	}
+
+	pub, priv, err := sentencecipher.GenerateKeyPair()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating key pair: %v\n", err)
+		return 1
+	}
+
+	return writeKeyPair(*outputName, pub.String(), priv.String())
+}
+
+// writeKeyPair writes NAME.key (owner-only) and NAME.pub, then prints the public key
+func writeKeyPair(name, public, private string) int {
+	keyFile := name + ".key"
+	if err := os.WriteFile(keyFile, []byte(private+"\n"), 0600); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 1
+	}
+	pubFile := name + ".pub"
+	if err := os.WriteFile(pubFile, []byte(public+"\n"), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s and %s\n", pubFile, keyFile)
+	fmt.Println(public)
+	return 0
+}
+
+func readPublicKey(path string) (*sentencecipher.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sentencecipher.ParsePublicKey(string(data))
+}
+
+func readPrivateKey(path string) (*sentencecipher.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sentencecipher.ParsePrivateKey(string(data))
+}
+
+func readSigningKey(path string) (*sentencecipher.SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sentencecipher.ParseSigningKey(string(data))
+}
+
+func readVerifyingKey(path string) (*sentencecipher.VerifyingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return sentencecipher.ParseVerifyingKey(string(data))
+}
@@ -2,22 +2,68 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	sentencecipher "github.com/kittizz/sentence-cipher"
+	"github.com/kittizz/sentence-cipher/agent"
 )
 
 const version = "1.0.0"
 
+// Exit codes
+const (
+	exitError        = 1
+	exitBadSignature = 3 // -verify: signature does not match
+	exitUnsigned     = 4 // -verify: message carries no signature
+)
+
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "split":
+			os.Exit(runSplit(os.Args[2:]))
+		case "join":
+			os.Exit(runJoin(os.Args[2:]))
+		case "keygen":
+			os.Exit(runKeygen(os.Args[2:]))
+		case "agent":
+			os.Exit(runAgent(os.Args[2:]))
+		case "session":
+			os.Exit(runSession(os.Args[2:]))
+		case "keyring":
+			os.Exit(runKeyring(os.Args[2:]))
+		case "theme":
+			os.Exit(runTheme(os.Args[2:]))
+		}
+	}
+
 	// Flags
 	decodeFlag := flag.Bool("d", false, "Decode mode (default is encode)")
 	naturalFlag := flag.Bool("n", false, "Use natural encoding (more varied sentences)")
+	markdownFlag := flag.Bool("m", false, "Use Markdown meeting-notes encoding")
+	fluentFlag := flag.Bool("f", false, "Use fluent prose from the built-in language model")
 	keyFlag := flag.String("k", "", "Encryption key (shuffles word lists)")
+	agentFlag := flag.String("a", "", "Use the key NAME held by the running agent instead of -k")
+	recipientFlag := flag.String("r", "", "Encrypt to the recipient's public key file")
+	identityFlag := flag.String("identity", "", "Decrypt with this private key file")
+	sessionFlag := flag.String("session", "", "Use and update the conversation state FILE (from session init)")
+	signFlag := flag.String("sign", "", "Sign the encoded output with this signing key file")
+	verifyFlag := flag.String("verify", "", "Verify the input's signature with this verifying key file before decoding")
+	padFlag := flag.String("pad", "", "Pad to hide the length: pow2, SIZE or MIN-MAX")
+	scanFlag := flag.Bool("scan", false, "With -d: decode every encoded block found in a larger text (e.g. a mail thread)")
+	transcriptFlag := flag.Bool("transcript", false, "With -d: decode a speech-to-text transcript (punctuation optional)")
+	spokenFlag := flag.Bool("spoken", false, "Use homophone-safe word lists for messages read aloud")
+	themeFlag := flag.String("theme", "", "Load a theme FILE (JSON) and write natural and Markdown text with it")
+	grammarFlag := flag.String("grammar", "", "Use the sentence patterns of a grammar FILE (both sides must use it)")
+	randomFlag := flag.Bool("random", false, "Mix a random nonce into the encoding so repeated messages look different")
+	chaffFlag := flag.Float64("chaff", 0, "Mix RATIO chaff sentences per data sentence into the output (needs -k)")
 	inputFile := flag.String("i", "", "Input file (default: stdin)")
 	outputFile := flag.String("o", "", "Output file (default: stdout)")
 	versionFlag := flag.Bool("v", false, "Show version")
@@ -28,11 +74,46 @@ func main() {
 
 Usage:
   grammarcipher [options] [text]
+  grammarcipher split [-k KEY] [-s N | -c N] [-i FILE] [-o PREFIX]
+  grammarcipher join [-k KEY] [-o FILE] [message files...]
+  grammarcipher keygen [-sign] -o NAME
+  grammarcipher agent [-socket PATH] [-timeout 15m] [add NAME | list | remove NAME]
+  grammarcipher session init -k KEY -self NAME -peer NAME -o FILE
+  grammarcipher theme build CORPUS -o THEME.json [-name NAME]
 
 Options:
   -d          Decode mode (default is encode)
   -n          Use natural encoding (more varied sentences)
+  -m          Use Markdown meeting-notes encoding
+  -f          Use fluent prose chosen word by word by a language model
   -k KEY      Encryption key (shuffles word lists for added security)
+  -a NAME     Use key NAME from the running agent (keeps keys out of shell history)
+  -r FILE     Encrypt to a recipient's public key (from keygen)
+  -identity FILE
+              Decrypt with your private key (from keygen)
+  -session FILE
+              Encode or decode within a conversation (fresh key per message)
+  -sign FILE  Sign the encoded output (key from keygen -sign)
+  -verify FILE
+              Verify the signature before decoding; exits with 3 for a bad
+              signature and 4 for an unsigned message
+  -pad POLICY Hide the message length: pow2 (power-of-two buckets), SIZE
+              (multiples of SIZE bytes) or MIN-MAX (random extra bytes)
+  -scan       With -d, find and decode every encoded block in a larger text
+              such as a whole mail thread
+  -transcript With -d, decode a speech-to-text transcript that may lack
+              punctuation (plain mode, or natural mode with -n)
+  -spoken     Use homophone-safe word lists for messages read aloud (both
+              sides must use it)
+  -theme FILE Load a theme (from theme build) and write natural and Markdown
+              text with it; decoding needs the file too
+  -grammar FILE
+              Write and read sentences in the shapes of a grammar file (both
+              sides must use it)
+  -random     Mix a random nonce into the encoding (repeated messages look different)
+  -chaff RATIO
+              Mix RATIO decoy sentences per data sentence into the output;
+              only the key holder can filter them out (needs -k)
   -i FILE     Read input from file
   -o FILE     Write output to file
   -v          Show version
@@ -48,6 +129,12 @@ Examples:
   # Encode with natural mode and key
   grammarcipher -n -k "my-secret-key" "Secret message"
   
+  # Encode as Markdown meeting notes
+  grammarcipher -m "Secret message"
+  
+  # Encode as fluent prose
+  grammarcipher -f -k "my-secret-key" "Secret message"
+  
   # Decode text with key
   grammarcipher -d -k "my-secret-key" "Tom loves Mary books."
   
@@ -56,6 +143,46 @@ Examples:
   
   # Decode from stdin
   echo "Tom loves Mary books." | grammarcipher -d
+  
+  # Keep keys in the agent instead of on the command line
+  grammarcipher agent &
+  grammarcipher agent add team          # prompts for the passphrase
+  grammarcipher -a team -n "Secret message"
+  
+  # Team keys in an encrypted keyring file; decode finds the right key
+  grammarcipher keyring add -f team.keyring design   # prompts for the passphrase and key
+  grammarcipher keyring encode -f team.keyring design "Secret message"
+  grammarcipher keyring decode -f team.keyring -i message.txt
+  
+  # Public-key mode: the recipient creates a key pair and shares alice.pub
+  grammarcipher keygen -o alice
+  grammarcipher -r alice.pub "Secret message"
+  grammarcipher -d -identity alice.key "Tom loves Mary books."
+  
+  # Conversation with a fresh key per message (bob runs init with names swapped)
+  grammarcipher session init -k "my-secret-key" -self alice -peer bob -o bob.session
+  grammarcipher -session bob.session "Secret message"
+  grammarcipher -d -session bob.session -i reply.txt
+  
+  # Signed messages
+  grammarcipher keygen -sign -o alice
+  grammarcipher -sign alice.signing.key "Approved"
+  grammarcipher -d -verify alice.signing.pub -i signed.txt
+  
+  # Read a message aloud, then decode the recognizer's transcript
+  grammarcipher -spoken -k "my-secret-key" "Secret message"
+  grammarcipher -d -spoken -transcript -k "my-secret-key" -i transcript.txt
+  
+  # Write in the sentence shapes of a grammar file
+  grammarcipher -grammar status.grammar -k "my-secret-key" "Secret message"
+  
+  # Build a theme from your own mail and write with it
+  grammarcipher theme build corpus.txt -o mytheme.json
+  grammarcipher -n -theme mytheme.json -k "my-secret-key" "Secret message"
+  
+  # Split a large file into a thread of emails, then join them back
+  grammarcipher split -k "my-secret-key" -s 40 -i report.pdf -o thread
+  grammarcipher join -k "my-secret-key" -o report.pdf thread-*.txt
 
 `)
 	}
@@ -72,6 +199,40 @@ Examples:
 		os.Exit(0)
 	}
 
+	if *recipientFlag != "" && (*naturalFlag || *markdownFlag || *fluentFlag) {
+		fmt.Fprintln(os.Stderr, "Error: -r writes plain sentences and cannot be combined with -n, -m or -f")
+		os.Exit(1)
+	}
+	if *identityFlag != "" && (*naturalFlag || *markdownFlag || *fluentFlag || *scanFlag || *transcriptFlag) {
+		fmt.Fprintln(os.Stderr, "Error: -identity reads plain sentences and cannot be combined with -n, -m, -f, -scan or -transcript")
+		os.Exit(1)
+	}
+	if *agentFlag != "" {
+		set := setFlags("pad", "random", "chaff", "theme", "grammar", "spoken", "scan", "transcript")
+		if len(set) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: -a uses the agent's cipher as is and cannot be combined with %s\n",
+				strings.Join(set, ", "))
+			os.Exit(1)
+		}
+	}
+	if *sessionFlag != "" {
+		set := setFlags("m", "f", "pad", "random", "chaff", "theme", "grammar", "spoken", "scan", "transcript")
+		if len(set) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: -session writes plain or natural text and cannot be combined with %s\n",
+				strings.Join(set, ", "))
+			os.Exit(1)
+		}
+	}
+
+	if *verifyFlag != "" && !*decodeFlag {
+		fmt.Fprintln(os.Stderr, "Error: -verify checks a message before decoding and needs -d")
+		os.Exit(1)
+	}
+	if *signFlag != "" && *decodeFlag {
+		fmt.Fprintln(os.Stderr, "Error: -sign signs encoded output and cannot be combined with -d")
+		os.Exit(1)
+	}
+
 	// Get input
 	var inputData []byte
 	var inputText string
@@ -112,15 +273,46 @@ Examples:
 	}
 
 	// Create cipher (with or without key)
-	var cipher *sentencecipher.Cipher
-	if *keyFlag != "" {
-		cipher, err = sentencecipher.NewCipher(*keyFlag)
+	cipher, err := newCipher(*keyFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating cipher: %v\n", err)
+		os.Exit(1)
+	}
+	if *padFlag != "" {
+		padding, err := parsePadding(*padFlag)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating cipher: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		cipher.SetPadding(padding)
+	}
+	cipher.SetRandomized(*randomFlag)
+	cipher.SetSpokenVocabulary(*spokenFlag)
+	if *themeFlag != "" {
+		theme, err := sentencecipher.LoadTheme(*themeFlag)
+		if err == nil {
+			err = cipher.SetTheme(theme.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *grammarFlag != "" {
+		g, err := sentencecipher.LoadGrammar(*grammarFlag)
+		if err == nil {
+			err = cipher.SetGrammar(g)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *chaffFlag != 0 {
+		if err := cipher.SetChaffRatio(*chaffFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		cipher = sentencecipher.NewDefaultCipher()
 	}
 
 	// Process
@@ -128,9 +320,54 @@ Examples:
 	var outputData []byte
 	isBinaryOutput := false
 
+	if *verifyFlag != "" {
+		vk, err := readVerifyingKey(*verifyFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading verifying key: %v\n", err)
+			os.Exit(exitError)
+		}
+		inputText, err = sentencecipher.Verify(vk, inputText)
+		if errors.Is(err, sentencecipher.ErrNoSignature) {
+			fmt.Fprintln(os.Stderr, "Error: message is not signed")
+			os.Exit(exitUnsigned)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, "Error: BAD SIGNATURE")
+			os.Exit(exitBadSignature)
+		}
+		fmt.Fprintln(os.Stderr, "Good signature")
+	}
+
 	if *decodeFlag {
 		// Decode - output is raw bytes
-		if *naturalFlag {
+		if *agentFlag != "" {
+			mode := agentMode(*naturalFlag, *markdownFlag, *fluentFlag)
+			outputData, err = agent.NewClient("").Decode(*agentFlag, mode, inputText)
+		} else if *sessionFlag != "" {
+			err = withSession(*sessionFlag, func(s *sentencecipher.Session) (err error) {
+				if *naturalFlag {
+					outputData, err = s.DecodeNatural(inputText)
+				} else {
+					outputData, err = s.Decode(inputText)
+				}
+				return err
+			})
+		} else if *identityFlag != "" {
+			var priv *sentencecipher.PrivateKey
+			priv, err = readPrivateKey(*identityFlag)
+			if err == nil {
+				outputData, err = cipher.DecodeWith(priv, inputText)
+			}
+		} else if *scanFlag {
+			outputData, err = decodeAll(cipher, inputText)
+		} else if *transcriptFlag && *naturalFlag {
+			outputData, err = cipher.DecodeNaturalTranscript(inputText)
+		} else if *transcriptFlag {
+			outputData, err = cipher.DecodeTranscript(inputText)
+		} else if *fluentFlag {
+			outputData, err = cipher.DecodeFluent(inputText)
+		} else if *markdownFlag {
+			outputData, err = cipher.DecodeMarkdown(inputText)
+		} else if *naturalFlag {
 			outputData, err = cipher.DecodeNatural(inputText)
 		} else {
 			outputData, err = cipher.Decode(inputText)
@@ -142,7 +379,29 @@ Examples:
 		isBinaryOutput = true
 	} else {
 		// Encode - input is raw bytes, output is text
-		if *naturalFlag {
+		if *agentFlag != "" {
+			mode := agentMode(*naturalFlag, *markdownFlag, *fluentFlag)
+			outputText, err = agent.NewClient("").Encode(*agentFlag, mode, inputData)
+		} else if *sessionFlag != "" {
+			err = withSession(*sessionFlag, func(s *sentencecipher.Session) (err error) {
+				if *naturalFlag {
+					outputText, err = s.EncodeNatural(inputData)
+				} else {
+					outputText, err = s.Encode(inputData)
+				}
+				return err
+			})
+		} else if *recipientFlag != "" {
+			var pub *sentencecipher.PublicKey
+			pub, err = readPublicKey(*recipientFlag)
+			if err == nil {
+				outputText, err = cipher.EncodeFor(pub, inputData)
+			}
+		} else if *fluentFlag {
+			outputText, err = cipher.EncodeFluent(inputData)
+		} else if *markdownFlag {
+			outputText, err = cipher.EncodeMarkdown(inputData)
+		} else if *naturalFlag {
 			outputText, err = cipher.EncodeNatural(inputData)
 		} else {
 			outputText, err = cipher.Encode(inputData)
@@ -151,6 +410,17 @@ Examples:
 			fmt.Fprintf(os.Stderr, "Error encoding: %v\n", err)
 			os.Exit(1)
 		}
+		if *signFlag != "" {
+			var sk *sentencecipher.SigningKey
+			sk, err = readSigningKey(*signFlag)
+			if err == nil {
+				outputText, err = sentencecipher.Sign(sk, outputText)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error signing: %v\n", err)
+				os.Exit(1)
+			}
+		}
 	}
 
 	// Write output
@@ -177,6 +447,73 @@ Examples:
 	_ = isFileInput
 }
 
+// newCipher creates a keyed cipher, or the default cipher when key is empty
+func newCipher(key string) (*sentencecipher.Cipher, error) {
+	if key == "" {
+		return sentencecipher.NewDefaultCipher(), nil
+	}
+	return sentencecipher.NewCipher(key)
+}
+
+// decodeAll decodes every span found by Scan, one payload per line; spans
+// that fail to decode are reported on stderr
+func decodeAll(cipher *sentencecipher.Cipher, text string) ([]byte, error) {
+	results := cipher.DecodeAll(text)
+	var out []byte
+	decoded := 0
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping block at offset %d: %v\n", r.Start, r.Err)
+			continue
+		}
+		if decoded > 0 {
+			out = append(out, '\n')
+		}
+		out = append(out, r.Data...)
+		decoded++
+	}
+	if decoded == 0 {
+		return nil, errors.New("no encoded blocks found")
+	}
+	fmt.Fprintf(os.Stderr, "Decoded %d of %d blocks\n", decoded, len(results))
+	return out, nil
+}
+
+// setFlags returns those of the named flags given on the command line, as "-name"
+func setFlags(names ...string) []string {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var set []string
+	flag.Visit(func(f *flag.Flag) {
+		if wanted[f.Name] {
+			set = append(set, "-"+f.Name)
+		}
+	})
+	return set
+}
+
+// parsePadding parses the -pad policy
+func parsePadding(s string) (sentencecipher.Padding, error) {
+	if s == "pow2" {
+		return sentencecipher.PowerOfTwoPadding{Min: 64}, nil
+	}
+	if lo, hi, ok := strings.Cut(s, "-"); ok {
+		min, err1 := strconv.Atoi(lo)
+		max, err2 := strconv.Atoi(hi)
+		if err1 != nil || err2 != nil || min < 0 || max < min {
+			return nil, fmt.Errorf("invalid padding range %q", s)
+		}
+		return sentencecipher.RandomPadding{Min: min, Max: max}, nil
+	}
+	size, err := strconv.Atoi(s)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("invalid padding policy %q (want pow2, SIZE or MIN-MAX)", s)
+	}
+	return sentencecipher.FixedPadding{Size: size}, nil
+}
+
 func readStdin() (string, error) {
 	stat, _ := os.Stdin.Stat()
 	if (stat.Mode() & os.ModeCharDevice) != 0 {
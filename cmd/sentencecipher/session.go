@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+// runSession implements "session init": create a conversation state file for one contact
+func runSession(args []string) int {
+	if len(args) == 0 || args[0] != "init" {
+		fmt.Fprintln(os.Stderr, "Usage: grammarcipher session init -k KEY -self NAME -peer NAME -o FILE")
+		return 1
+	}
+
+	fs := flag.NewFlagSet("session init", flag.ExitOnError)
+	keyFlag := fs.String("k", "", "Shared key agreed with the contact")
+	selfFlag := fs.String("self", "", "Your name in the conversation")
+	peerFlag := fs.String("peer", "", "The contact's name in the conversation")
+	outputFile := fs.String("o", "", "Session state file to create")
+	fs.Parse(args[1:])
+
+	if *outputFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: -o FILE is required")
+		return 1
+	}
+	if _, err := os.Stat(*outputFile); err == nil {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists\n", *outputFile)
+		return 1
+	}
+
+	session, err := sentencecipher.NewSession(*keyFlag, *selfFlag, *peerFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating session: %v\n", err)
+		return 1
+	}
+	if err := session.Save(*outputFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing session: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s; the key is no longer needed for this conversation\n", *outputFile)
+	return 0
+}
+
+// withSession loads the session state at path, runs fn and saves the advanced
+// state, so a message key is never reused after a successful operation
+func withSession(path string, fn func(*sentencecipher.Session) error) error {
+	session, err := sentencecipher.LoadSession(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(session); err != nil {
+		return err
+	}
+	return session.Save(path)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// messageSeparator divides messages when a thread is written to or read from a single stream
+const messageSeparator = "\n\n-----\n\n"
+
+// runSplit implements "split": encode a payload as a thread of natural-mode emails
+func runSplit(args []string) int {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	keyFlag := fs.String("k", "", "Encryption key (shuffles word lists)")
+	sentencesFlag := fs.Int("s", 0, "Maximum sentences per message")
+	countFlag := fs.Int("c", 0, "Number of messages to produce")
+	inputFile := fs.String("i", "", "Input file (default: stdin)")
+	outputPrefix := fs.String("o", "", "Write messages to PREFIX-001.txt, PREFIX-002.txt, ... (default: stdout)")
+	fs.Parse(args)
+
+	if (*sentencesFlag > 0) == (*countFlag > 0) {
+		fmt.Fprintln(os.Stderr, "Error: exactly one of -s or -c is required")
+		return 1
+	}
+
+	var data []byte
+	var err error
+	if *inputFile != "" {
+		data, err = os.ReadFile(*inputFile)
+	} else if fs.NArg() > 0 {
+		data = []byte(strings.Join(fs.Args(), " "))
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		return 1
+	}
+
+	cipher, err := newCipher(*keyFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating cipher: %v\n", err)
+		return 1
+	}
+
+	var messages []string
+	if *countFlag > 0 {
+		messages, err = cipher.SplitN(data, *countFlag)
+	} else {
+		messages, err = cipher.Split(data, *sentencesFlag)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error splitting: %v\n", err)
+		return 1
+	}
+
+	if *outputPrefix == "" {
+		fmt.Println(strings.Join(messages, messageSeparator))
+		return 0
+	}
+	for i, msg := range messages {
+		name := fmt.Sprintf("%s-%03d.txt", *outputPrefix, i+1)
+		if err := os.WriteFile(name, []byte(msg+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 1
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d messages\n", len(messages))
+	return 0
+}
+
+// runJoin implements "join": rebuild a payload from split messages in any order
+func runJoin(args []string) int {
+	fs := flag.NewFlagSet("join", flag.ExitOnError)
+	keyFlag := fs.String("k", "", "Encryption key (shuffles word lists)")
+	outputFile := fs.String("o", "", "Output file (default: stdout)")
+	fs.Parse(args)
+
+	var messages []string
+	if fs.NArg() > 0 {
+		for _, name := range fs.Args() {
+			data, err := os.ReadFile(name)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+				return 1
+			}
+			messages = append(messages, string(data))
+		}
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return 1
+		}
+		messages = strings.Split(string(data), strings.TrimSpace(messageSeparator))
+	}
+
+	cipher, err := newCipher(*keyFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating cipher: %v\n", err)
+		return 1
+	}
+
+	joiner := cipher.NewJoiner()
+	for _, msg := range messages {
+		if strings.TrimSpace(msg) == "" {
+			continue
+		}
+		if _, err := joiner.Add(msg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error decoding message: %v\n", err)
+			return 1
+		}
+		received, total := joiner.Progress()
+		fmt.Fprintf(os.Stderr, "Received %d/%d\n", received, total)
+	}
+
+	data, err := joiner.Bytes()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error joining: %v\n", err)
+		return 1
+	}
+
+	if *outputFile != "" {
+		if err := os.WriteFile(*outputFile, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	os.Stdout.Write(data)
+	return 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	sentencecipher "github.com/kittizz/sentence-cipher"
+)
+
+// runTheme implements "theme build": derive a theme from a corpus of the team's writing
+func runTheme(args []string) int {
+	if len(args) == 0 || args[0] != "build" {
+		fmt.Fprintln(os.Stderr, "Usage: grammarcipher theme build CORPUS -o THEME.json [-name NAME]")
+		return 1
+	}
+	fs := flag.NewFlagSet("theme build", flag.ExitOnError)
+	outputFile := fs.String("o", "", "Write the theme to FILE (JSON)")
+	nameFlag := fs.String("name", "", "Theme name (default: the output file name)")
+	fs.Parse(args[1:])
+
+	// Flags may also follow the corpus file
+	var corpusFiles []string
+	for fs.NArg() > 0 {
+		corpusFiles = append(corpusFiles, fs.Arg(0))
+		fs.Parse(fs.Args()[1:])
+	}
+	if len(corpusFiles) == 0 || *outputFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: a corpus file and -o are required")
+		return 1
+	}
+
+	var corpus strings.Builder
+	for _, name := range corpusFiles {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading corpus: %v\n", err)
+			return 1
+		}
+		corpus.Write(data)
+		corpus.WriteString("\n")
+	}
+
+	name := *nameFlag
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(*outputFile), filepath.Ext(*outputFile))
+	}
+	theme, stats, err := sentencecipher.BuildTheme(name, corpus.String())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building theme: %v\n", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(theme, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding theme: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*outputFile, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Wrote theme %q: from the corpus %d/256 verbs, %d/256 objects, %d names, %d subjects, %d openers, %d closers\n",
+		name, stats.Verbs, stats.Objects, stats.Names, stats.Subjects, stats.Openers, stats.Closers)
+	return 0
+}
@@ -0,0 +1,153 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Deniable encoding hides a second message in the indirect-object slot.
+//
+// The visible sentences are a normal encoding of the decoy under decoyKey. The
+// indirect object of the k-th full sentence is realCipher.names[hidden[k]], where
+//
+//	hidden = tag(4) || (length(2) || compressed real || random padding) XOR keystream
+//
+// keystream and tag are derived from realKey. Since plain-mode encoding already
+// fills that slot with random names, the result looks like any other encoding.
+const (
+	hiddenTagSize    = 4
+	hiddenLengthSize = 2
+	// deniableAttempts bounds the retries of EncodeDeniable
+	deniableAttempts = 8
+)
+
+// EncodeDeniable produces plain-mode text that decodes to decoy under decoyKey
+// and to real under realKey. The decoy must be long enough to carry the real
+// message: each full sentence hides one byte.
+func EncodeDeniable(real []byte, realKey string, decoy []byte, decoyKey string) (string, error) {
+	if realKey == decoyKey {
+		return "", errors.New("real and decoy keys must differ")
+	}
+	realCipher, err := NewCipher(realKey)
+	if err != nil {
+		return "", err
+	}
+	decoyCipher, err := NewCipher(decoyKey)
+	if err != nil {
+		return "", err
+	}
+
+	compressedReal, err := compress(real)
+	if err != nil {
+		return "", fmt.Errorf("compression failed: %w", err)
+	}
+	compressedDecoy, err := compress(decoy)
+	if err != nil {
+		return "", fmt.Errorf("compression failed: %w", err)
+	}
+
+	capacity := 0
+	for _, plan := range planSentences(compressedDecoy, decoyCipher.patternSet().bits, decoyCipher.patternSet().endingBits) {
+		if len(plan.values) == 3 {
+			capacity++
+		}
+	}
+	need := hiddenTagSize + hiddenLengthSize + len(compressedReal)
+	if need > capacity || len(compressedReal) > 0xFFFF {
+		return "", fmt.Errorf("decoy too short: it hides %d bytes, the real message needs %d", capacity, need)
+	}
+
+	// A hidden byte that would make its decoy sentence read as chaff or a nonce
+	// gets moved (see encodeRawWithIndirect). The tag changes with the random
+	// padding, so retry; a collision in the fixed bytes needs another decoy.
+	for attempt := 0; attempt < deniableAttempts; attempt++ {
+		body := make([]byte, capacity-hiddenTagSize)
+		binary.BigEndian.PutUint16(body, uint16(len(compressedReal)))
+		copy(body[hiddenLengthSize:], compressedReal)
+		if _, err := rand.Read(body[hiddenLengthSize+len(compressedReal):]); err != nil {
+			return "", fmt.Errorf("padding generation failed: %w", err)
+		}
+		realCipher.xorHiddenKeystream(body)
+		hidden := append(realCipher.hiddenTag(body), body...)
+
+		indirect := make([]string, len(hidden))
+		for k, h := range hidden {
+			indirect[k] = realCipher.names[h]
+		}
+		text := decoyCipher.encodeRawWithIndirect(compressedDecoy, indirect)
+		if got, ok := realCipher.decodeHidden(text); ok && bytes.Equal(got, real) {
+			return text, nil
+		}
+	}
+	return "", errors.New("the real message collides with the decoy's chaff tags, use another decoy")
+}
+
+// decodeHidden recovers a message hidden for this cipher's key by EncodeDeniable.
+// It reports false when the text carries no message for this key.
+func (c *Cipher) decodeHidden(encoded string) ([]byte, bool) {
+	if c.key == "" {
+		return nil, false
+	}
+
+	var hidden []byte
+	for _, sentence := range splitSentences(normalizeText(encoded)) {
+		words := strings.Fields(strings.ToLower(trimSentenceEnd(sentence)))
+		// The real key's lists hold the same words as the decoy's
+		p, ok := c.parseSentence(words)
+		if !ok {
+			return nil, false
+		}
+		if p.size == 3 {
+			hidden = append(hidden, byte(p.io))
+		}
+	}
+	if len(hidden) < hiddenTagSize+hiddenLengthSize {
+		return nil, false
+	}
+
+	tag, body := hidden[:hiddenTagSize], hidden[hiddenTagSize:]
+	if !hmac.Equal(tag, c.hiddenTag(body)) {
+		return nil, false
+	}
+
+	plain := append([]byte{}, body...)
+	c.xorHiddenKeystream(plain)
+	n := int(binary.BigEndian.Uint16(plain))
+	if hiddenLengthSize+n > len(plain) {
+		return nil, false
+	}
+	data, err := decompress(plain[hiddenLengthSize : hiddenLengthSize+n])
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// hiddenTag authenticates the hidden body under this cipher's key
+func (c *Cipher) hiddenTag(body []byte) []byte {
+	macKey := sha256.Sum256([]byte("sentence-cipher/deniable/mac:" + c.key))
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write(body)
+	return mac.Sum(nil)[:hiddenTagSize]
+}
+
+// xorHiddenKeystream encrypts or decrypts the hidden body in place
+// (SHA-256 in counter mode keyed by this cipher's key)
+func (c *Cipher) xorHiddenKeystream(data []byte) {
+	encKey := sha256.Sum256([]byte("sentence-cipher/deniable/enc:" + c.key))
+	var block [sha256.Size]byte
+	var counter [8]byte
+	for i := range data {
+		if i%sha256.Size == 0 {
+			binary.BigEndian.PutUint64(counter[:], uint64(i/sha256.Size))
+			block = sha256.Sum256(append(encKey[:], counter[:]...))
+		}
+		data[i] ^= block[i%sha256.Size]
+	}
+}
@@ -0,0 +1,98 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeDeniable(t *testing.T) {
+	real := []byte("The drop is at pier 4, midnight.")
+	decoy := []byte(`Reminder: the quarterly review moved to Thursday afternoon.
+Please bring the updated budget figures, the hiring plan and the draft slides for the client.
+Marketing still owes us the campaign numbers from October, and finance wants a revised
+forecast before the board call. Legal signed off on the vendor contract yesterday, so
+procurement can start onboarding next week. Lunch will be provided; tell Priya about
+dietary restrictions by Wednesday noon.`)
+
+	text, err := EncodeDeniable(real, "real-key", decoy, "decoy-key")
+	if err != nil {
+		t.Fatalf("EncodeDeniable error: %v", err)
+	}
+
+	realCipher, _ := NewCipher("real-key")
+	decoyCipher, _ := NewCipher("decoy-key")
+
+	gotReal, err := realCipher.Decode(text)
+	if err != nil {
+		t.Fatalf("Decode with real key error: %v", err)
+	}
+	if !bytes.Equal(gotReal, real) {
+		t.Errorf("real key decoded %q, want %q", gotReal, real)
+	}
+
+	gotDecoy, err := decoyCipher.Decode(text)
+	if err != nil {
+		t.Fatalf("Decode with decoy key error: %v", err)
+	}
+	if !bytes.Equal(gotDecoy, decoy) {
+		t.Errorf("decoy key decoded %q, want %q", gotDecoy, decoy)
+	}
+
+	otherCipher, _ := NewCipher("other-key")
+	if got, err := otherCipher.Decode(text); err == nil && (bytes.Equal(got, real) || bytes.Equal(got, decoy)) {
+		t.Error("an unrelated key should not decode either message")
+	}
+}
+
+func TestEncodeDeniableDecoyTooShort(t *testing.T) {
+	_, err := EncodeDeniable([]byte("a fairly long real message that will not fit"), "real-key", []byte("hi"), "decoy-key")
+	if err == nil {
+		t.Error("expected error when the decoy cannot carry the real message")
+	}
+}
+
+func TestDecodeIgnoresHiddenChannelWithoutTag(t *testing.T) {
+	cipher, _ := NewCipher("plain-key")
+	input := []byte("An ordinary message with enough bytes to fill several sentences.")
+
+	// Random indirect objects must never be mistaken for a hidden message
+	for i := 0; i < 20; i++ {
+		encoded, err := cipher.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+		decoded, err := cipher.Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Fatalf("Decode mismatch: %q", decoded)
+		}
+	}
+}
+
+func TestIndirectWordsAvoidChaffTags(t *testing.T) {
+	cipher, _ := NewCipher("decoy-key")
+	data := []byte("three bytes per full sentence")
+
+	// Find an object byte that puts the first sentence's object on its chaff
+	// tag, then force the first indirect word onto the tag as well
+	var p sentenceParse
+	var io, o int
+	for b := 0; b < 256; b++ {
+		data[2] = byte(b)
+		p = planSentences(data, cipher.patternSet().bits, cipher.patternSet().endingBits)[0].slots(0)
+		if io, o = cipher.chaffTag(p.s, p.v); p.size == 3 && p.o == o {
+			break
+		}
+	}
+	if p.o != o {
+		t.Fatal("no object byte matches the chaff tag")
+	}
+	text := cipher.encodeRawWithIndirect(data, []string{cipher.names[io]})
+
+	decoded, err := cipher.decodeRaw(text)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("decodeRaw = %q, %v", decoded, err)
+	}
+}
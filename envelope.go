@@ -0,0 +1,68 @@
+package sentencecipher
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// Natural-mode envelope.
+//
+// The theme, subject, opener, closer, connectors and sender are chosen from
+// a random seed, so the envelope says nothing about the payload. The seed is
+// not transmitted: the decoder reads the theme from the body instead. The indirect object of the k-th data sentence
+// (otherwise ignored) has parity themeBit XOR mask(k), with mask a keyed bit
+// stream, so every full sentence votes for the theme and a rewritten subject
+// line no longer breaks decoding.
+
+// envelopeSeed picks the envelope seed for a natural-mode or Markdown message
+// (the payload-derived coverSeed if the system random source fails)
+func (c *Cipher) envelopeSeed(data []byte) int {
+	return randomIntOr(10000, c.coverSeed(data))
+}
+
+// themeMask returns the keyed mask bit for the k-th data sentence
+func (c *Cipher) themeMask(k int) int {
+	var block [4]byte
+	binary.BigEndian.PutUint32(block[:], uint32(k/256))
+	sum := sha256.Sum256(append([]byte("sentence-cipher/theme:"+c.key+":"), block[:]...))
+	return int(sum[(k%256)/8]>>(k%8)) & 1
+}
+
+// themeParity adjusts an indirect-object index so the k-th data sentence votes for theme
+func (c *Cipher) themeParity(theme string, k, io int) int {
+	if io&1 != themeBit(theme)^c.themeMask(k) {
+		io ^= 1
+	}
+	return io
+}
+
+// themeVotes counts the indirect-object votes of the body sentences for
+// theme, minus the votes against it and the sentences outside its vocabulary
+func (c *Cipher) themeVotes(theme string, sentences []string) int {
+	themed := c.themed(theme)
+	score, k, nonce := 0, 0, 0
+	for _, sentence := range sentences {
+		words := naturalWords(sentence)
+		s, v, io, o, ok := themed.fullSentenceIndices(words)
+		if !ok {
+			if len(words) > 3 { // looks like a full sentence outside the theme's lists
+				score--
+			}
+			continue
+		}
+		if c.isChaff(s, v, io, o) {
+			continue
+		}
+		if k == 0 && nonce < nonceSentenceCount && c.isNonce(nonce, s, v, io, o) {
+			nonce++
+			continue
+		}
+		if io&1 == themeBit(theme)^c.themeMask(k) {
+			score++
+		} else {
+			score--
+		}
+		k++
+	}
+	return score
+}
@@ -0,0 +1,57 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var subjectLine = regexp.MustCompile(`(?m)^Subject: .*$`)
+
+func TestNaturalSurvivesRewrittenSubject(t *testing.T) {
+	for _, key := range []string{"", "envelope-key"} {
+		cipher := NewDefaultCipher()
+		if key != "" {
+			cipher, _ = NewCipher(key)
+		}
+		input := []byte("The subject line is not part of the message")
+
+		for i := 0; i < 8; i++ {
+			encoded, err := cipher.EncodeNatural(input)
+			if err != nil {
+				t.Fatalf("EncodeNatural error: %v", err)
+			}
+			for _, subject := range []string{"Subject: Re: Fwd: lunch?", "Subject: Server Maintenance", ""} {
+				rewritten := subjectLine.ReplaceAllString(encoded, subject)
+				decoded, err := cipher.DecodeNatural(rewritten)
+				if err != nil {
+					t.Fatalf("key %q, subject %q: DecodeNatural error: %v", key, subject, err)
+				}
+				if !bytes.Equal(decoded, input) {
+					t.Errorf("key %q, subject %q: decoded %q", key, subject, decoded)
+				}
+			}
+		}
+	}
+}
+
+func TestEnvelopeIndependentOfPayload(t *testing.T) {
+	cipher, _ := NewCipher("envelope-key")
+	input := []byte("Same payload")
+
+	subjects := make(map[string]bool)
+	themes := make(map[string]bool)
+	for i := 0; i < 40; i++ {
+		encoded, _ := cipher.EncodeNatural(input)
+		subject := subjectLine.FindString(encoded)
+		subjects[subject] = true
+		themes[themeForSubject(strings.TrimPrefix(subject, "Subject: "))] = true
+	}
+	if len(subjects) < 2 {
+		t.Errorf("the same payload always got subject %v", subjects)
+	}
+	if len(themes) != 2 {
+		t.Errorf("the same payload always got theme %v", themes)
+	}
+}
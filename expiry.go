@@ -0,0 +1,270 @@
+package sentencecipher
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message header for expiry and replay protection, placed before the
+// compressed data in the keyed layer:
+// - Bytes 0-1:   magic 0x53 0xE7
+// - Bytes 2-5:   creation time (unix seconds, big endian)
+// - Bytes 6-9:   expiry time (unix seconds, 0 = never)
+// - Bytes 10-17: random message ID
+// - Bytes 18-25: HMAC-SHA256(key, header fields || compressed data)
+//
+// The MAC keeps the times from being edited and tells a header apart from a
+// brotli stream that happens to start with the magic bytes.
+const (
+	messageHeaderSize = 26
+	messageTagSize    = 8
+)
+
+var messageHeaderMagic = []byte{0x53, 0xE7}
+
+// timeNow is replaced in tests
+var timeNow = time.Now
+
+// ExpiredError is returned when a message is past its expiry time
+type ExpiredError struct {
+	ExpiredAt time.Time
+}
+
+func (e *ExpiredError) Error() string {
+	return fmt.Sprintf("message expired at %s", e.ExpiredAt.UTC().Format(time.RFC3339))
+}
+
+// ReplayError is returned when a message ID has been seen before
+type ReplayError struct {
+	ID string
+}
+
+func (e *ReplayError) Error() string {
+	return "message already seen: " + e.ID
+}
+
+// ReplayCache remembers message IDs until they expire
+type ReplayCache interface {
+	// CheckAndStore records id and reports whether it was already present.
+	// A zero expires means the ID must be kept forever.
+	CheckAndStore(id string, expires time.Time) (seen bool, err error)
+}
+
+// SetReplayCache makes Decode, DecodeNatural and DecodeMarkdown reject
+// messages whose ID has already been seen (nil disables the check)
+func (c *Cipher) SetReplayCache(cache ReplayCache) {
+	c.replay = cache
+}
+
+// EncodeExpiring is Encode with a header carrying a creation time, a random
+// message ID and, when ttl > 0, an expiry time
+func (c *Cipher) EncodeExpiring(data []byte, ttl time.Duration) (string, error) {
+	raw, err := c.withMessageHeader(data, ttl)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeRaw(raw), nil
+}
+
+// EncodeNaturalExpiring is EncodeNatural with an expiry/replay header
+func (c *Cipher) EncodeNaturalExpiring(data []byte, ttl time.Duration) (string, error) {
+	raw, err := c.withMessageHeader(data, ttl)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeNaturalRaw(raw), nil
+}
+
+// withMessageHeader compresses data, prepends the message header and pads
+func (c *Cipher) withMessageHeader(data []byte, ttl time.Duration) ([]byte, error) {
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	now := timeNow()
+	header := make([]byte, messageHeaderSize-messageTagSize, messageHeaderSize+len(compressed))
+	copy(header, messageHeaderMagic)
+	binary.BigEndian.PutUint32(header[2:6], uint32(now.Unix()))
+	if ttl > 0 {
+		binary.BigEndian.PutUint32(header[6:10], uint32(now.Add(ttl).Unix()))
+	}
+	if _, err := rand.Read(header[10:18]); err != nil {
+		return nil, fmt.Errorf("message ID generation failed: %w", err)
+	}
+
+	header = append(header, c.messageTag(header, compressed)...)
+	return c.padPayload(append(header, compressed...))
+}
+
+func (c *Cipher) messageTag(fields, compressed []byte) []byte {
+	mac := hmac.New(sha256.New, []byte("sentence-cipher/message:"+c.key))
+	mac.Write(fields)
+	mac.Write(compressed)
+	return mac.Sum(nil)[:messageTagSize]
+}
+
+// openPayload strips padding, checks the optional message header then decompresses (internal use)
+func (c *Cipher) openPayload(raw []byte) ([]byte, error) {
+	raw = c.unpadPayload(raw)
+	compressed := raw
+	if len(raw) > messageHeaderSize && bytes.Equal(raw[:2], messageHeaderMagic) {
+		fields := raw[:messageHeaderSize-messageTagSize]
+		tag := raw[messageHeaderSize-messageTagSize : messageHeaderSize]
+		body := raw[messageHeaderSize:]
+
+		if hmac.Equal(tag, c.messageTag(fields, body)) {
+			var expires time.Time
+			if exp := binary.BigEndian.Uint32(fields[6:10]); exp != 0 {
+				expires = time.Unix(int64(exp), 0)
+				if timeNow().After(expires) {
+					return nil, &ExpiredError{ExpiredAt: expires}
+				}
+			}
+			if c.replay != nil {
+				id := hex.EncodeToString(fields[10:18])
+				seen, err := c.replay.CheckAndStore(id, expires)
+				if err != nil {
+					return nil, fmt.Errorf("replay cache: %w", err)
+				}
+				if seen {
+					return nil, &ReplayError{ID: id}
+				}
+			}
+			compressed = body
+		}
+	}
+
+	decompressed, err := decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed: %w", err)
+	}
+	return decompressed, nil
+}
+
+// MemoryReplayCache is an in-memory ReplayCache
+type MemoryReplayCache struct {
+	mu  sync.Mutex
+	ids map[string]time.Time
+}
+
+// NewMemoryReplayCache creates an empty in-memory cache
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{ids: make(map[string]time.Time)}
+}
+
+// CheckAndStore implements ReplayCache
+func (m *MemoryReplayCache) CheckAndStore(id string, expires time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	if _, ok := m.ids[id]; ok {
+		return true, nil
+	}
+	m.ids[id] = expires
+	return false, nil
+}
+
+// prune drops IDs whose messages have expired (they are rejected as expired anyway)
+func (m *MemoryReplayCache) prune() {
+	now := timeNow()
+	for id, expires := range m.ids {
+		if !expires.IsZero() && now.After(expires) {
+			delete(m.ids, id)
+		}
+	}
+}
+
+// FileReplayCache is a ReplayCache persisted to a file, one "id expiry" line per message
+type FileReplayCache struct {
+	path string
+	mem  *MemoryReplayCache
+}
+
+// NewFileReplayCache loads (or creates) the cache stored at path
+func NewFileReplayCache(path string) (*FileReplayCache, error) {
+	cache := &FileReplayCache{path: path, mem: NewMemoryReplayCache()}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		var expires time.Time
+		if unix, err := strconv.ParseInt(fields[1], 10, 64); err == nil && unix != 0 {
+			expires = time.Unix(unix, 0)
+		}
+		cache.mem.ids[fields[0]] = expires
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	cache.mem.prune()
+	return cache, cache.rewrite()
+}
+
+// CheckAndStore implements ReplayCache
+func (f *FileReplayCache) CheckAndStore(id string, expires time.Time) (bool, error) {
+	seen, err := f.mem.CheckAndStore(id, expires)
+	if err != nil || seen {
+		return seen, err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s %d\n", id, unixOrZero(expires))
+	return false, err
+}
+
+// rewrite compacts the file to the unexpired entries
+func (f *FileReplayCache) rewrite() error {
+	f.mem.mu.Lock()
+	defer f.mem.mu.Unlock()
+
+	var buf bytes.Buffer
+	for id, expires := range f.mem.ids {
+		fmt.Fprintf(&buf, "%s %d\n", id, unixOrZero(expires))
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// EncodeExpiring compresses then encodes with an expiry/replay header (package-level)
+func EncodeExpiring(data []byte, ttl time.Duration) (string, error) {
+	return NewDefaultCipher().EncodeExpiring(data, ttl)
+}
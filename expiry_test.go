@@ -0,0 +1,154 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func withClock(t *testing.T, now time.Time) *time.Time {
+	clock := now
+	timeNow = func() time.Time { return clock }
+	t.Cleanup(func() { timeNow = time.Now })
+	return &clock
+}
+
+func TestEncodeExpiringRoundTrip(t *testing.T) {
+	cipher, _ := NewCipher("expiry-key")
+	input := []byte("This message self-destructs")
+
+	encoded, err := cipher.EncodeExpiring(input, time.Hour)
+	if err != nil {
+		t.Fatalf("EncodeExpiring error: %v", err)
+	}
+	decoded, err := cipher.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("decoded %q, want %q", decoded, input)
+	}
+
+	natural, err := cipher.EncodeNaturalExpiring(input, 0)
+	if err != nil {
+		t.Fatalf("EncodeNaturalExpiring error: %v", err)
+	}
+	decoded, err = cipher.DecodeNatural(natural)
+	if err != nil {
+		t.Fatalf("DecodeNatural error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("natural decoded %q, want %q", decoded, input)
+	}
+}
+
+func TestDecodeExpired(t *testing.T) {
+	clock := withClock(t, time.Unix(1700000000, 0))
+	cipher, _ := NewCipher("expiry-key")
+
+	encoded, err := cipher.EncodeExpiring([]byte("short lived"), time.Minute)
+	if err != nil {
+		t.Fatalf("EncodeExpiring error: %v", err)
+	}
+	if _, err := cipher.Decode(encoded); err != nil {
+		t.Fatalf("Decode before expiry: %v", err)
+	}
+
+	*clock = clock.Add(2 * time.Minute)
+	_, err = cipher.Decode(encoded)
+	var expired *ExpiredError
+	if !errors.As(err, &expired) {
+		t.Fatalf("expected ExpiredError, got %v", err)
+	}
+	if !expired.ExpiredAt.Equal(time.Unix(1700000060, 0)) {
+		t.Errorf("ExpiredAt = %v", expired.ExpiredAt)
+	}
+}
+
+func TestDecodeReplay(t *testing.T) {
+	cipher, _ := NewCipher("replay-key")
+	cipher.SetReplayCache(NewMemoryReplayCache())
+
+	encoded, _ := cipher.EncodeExpiring([]byte("say it once"), time.Hour)
+	if _, err := cipher.Decode(encoded); err != nil {
+		t.Fatalf("first Decode error: %v", err)
+	}
+	_, err := cipher.Decode(encoded)
+	var replay *ReplayError
+	if !errors.As(err, &replay) {
+		t.Fatalf("expected ReplayError, got %v", err)
+	}
+
+	// A fresh encoding of the same data has a new message ID
+	again, _ := cipher.EncodeExpiring([]byte("say it once"), time.Hour)
+	if _, err := cipher.Decode(again); err != nil {
+		t.Errorf("Decode of new message error: %v", err)
+	}
+
+	// Messages without a header are not tracked
+	plain, _ := cipher.Encode([]byte("no header"))
+	for i := 0; i < 2; i++ {
+		if _, err := cipher.Decode(plain); err != nil {
+			t.Errorf("Decode of headerless message error: %v", err)
+		}
+	}
+}
+
+func TestHeaderNeedsKey(t *testing.T) {
+	sender, _ := NewCipher("sender-key")
+	other, _ := NewCipher("other-key")
+	other.SetReplayCache(NewMemoryReplayCache())
+
+	encoded, _ := sender.EncodeExpiring([]byte("not for you"), time.Hour)
+	for i := 0; i < 2; i++ {
+		if _, err := other.Decode(encoded); err == nil {
+			t.Error("expected error decoding with the wrong key")
+		}
+	}
+}
+
+func TestFileReplayCache(t *testing.T) {
+	clock := withClock(t, time.Unix(1700000000, 0))
+	dir, err := os.MkdirTemp("", "screplay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "seen")
+
+	cache, err := NewFileReplayCache(path)
+	if err != nil {
+		t.Fatalf("NewFileReplayCache error: %v", err)
+	}
+	if seen, err := cache.CheckAndStore("aa", clock.Add(time.Minute)); err != nil || seen {
+		t.Fatalf("first CheckAndStore = %v, %v", seen, err)
+	}
+	if seen, _ := cache.CheckAndStore("bb", time.Time{}); seen {
+		t.Fatal("bb reported as seen")
+	}
+
+	// Entries survive a reload
+	reloaded, err := NewFileReplayCache(path)
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if seen, _ := reloaded.CheckAndStore("aa", clock.Add(time.Minute)); !seen {
+		t.Error("aa should be remembered after reload")
+	}
+
+	// Expired entries are pruned on load, entries without expiry are kept
+	*clock = clock.Add(time.Hour)
+	pruned, err := NewFileReplayCache(path)
+	if err != nil {
+		t.Fatalf("reload error: %v", err)
+	}
+	if seen, _ := pruned.CheckAndStore("aa", time.Time{}); seen {
+		t.Error("expired aa should have been pruned")
+	}
+	if seen, _ := pruned.CheckAndStore("bb", time.Time{}); !seen {
+		t.Error("bb has no expiry and should be kept")
+	}
+}
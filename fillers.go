@@ -0,0 +1,105 @@
+package sentencecipher
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+)
+
+// Short sentences end in a filler instead of an object: "Name <work ending>."
+// carries 1 byte and "Name verb <daily ending>." 2 bytes. Each key draws its
+// own pool of fillerPoolSize endings from the lists below, so the endings
+// neither repeat nor identify the cipher. The index of the ending in the pool
+// carries fillerBits more bits of data where the layout has room for them (see
+// bitPlans); elsewhere the ending is picked at random. Decoding accepts every
+// ending in the lists, whatever the key, but only the key's pool carries data.
+//
+// No work ending ends in a daily ending, and none of their words is a name or
+// a verb of the built-in themes, so sentences and transcripts parse one way only.
+var workEndings = []string{
+	"works", "works remotely", "works late", "works hard",
+	"is away", "is out", "is traveling", "is presenting",
+	"is busy", "is offline", "is available", "is onboarding",
+	"stays late", "stays remote", "is around", "is back",
+}
+
+var dailyEndings = []string{
+	"daily", "weekly", "monthly", "today",
+	"tomorrow", "tonight", "again", "often",
+	"regularly", "quickly", "carefully", "early",
+	"later", "twice", "promptly", "personally",
+}
+
+const (
+	fillerBits     = 3
+	fillerPoolSize = 1 << fillerBits
+)
+
+// fillerPool returns the key's pool of endings from list
+func (c *Cipher) fillerPool(list []string) []string {
+	if c.key == "" {
+		return list[:fillerPoolSize]
+	}
+	hash := sha256.Sum256([]byte("sentence-cipher/fillers:" + c.key))
+	seed := int64(binary.BigEndian.Uint64(hash[:8]))
+	return shuffleWithSeed(list, seed)[:fillerPoolSize]
+}
+
+// fillerEnding returns ending i of the key's pool from list. For -1 (an
+// ending outside the pool was read) it returns the first such ending, so the
+// sentence reads back the same.
+func (c *Cipher) fillerEnding(list []string, i int) string {
+	pool := c.fillerPool(list)
+	if i >= 0 {
+		return pool[i]
+	}
+	for _, ending := range list {
+		if findIndex(pool, ending) == -1 {
+			return ending
+		}
+	}
+	return pool[0]
+}
+
+// fillerIndex returns the index of ending in the key's pool from list (-1 if absent)
+func (c *Cipher) fillerIndex(list []string, ending string) int {
+	return findIndex(c.fillerPool(list), ending)
+}
+
+// randomFiller picks a random index in a pool
+func randomFiller() int {
+	return randomIntOr(fillerPoolSize, 0)
+}
+
+// workEnding returns the words after the subject of a 1-byte sentence
+func (c *Cipher) workEnding(i int) string {
+	return c.fillerEnding(workEndings, i)
+}
+
+// dailyEnding returns the word after the verb of a 2-byte sentence
+func (c *Cipher) dailyEnding(i int) string {
+	return c.fillerEnding(dailyEndings, i)
+}
+
+// isWorkEnding reports whether words (after the subject) are a work ending
+func isWorkEnding(words []string) bool {
+	return matchWorkEnding(words) == len(words) && len(words) > 0
+}
+
+// matchWorkEnding returns the length in words of the longest work ending that
+// starts words (0 for none)
+func matchWorkEnding(words []string) int {
+	longest := 0
+	for _, ending := range workEndings {
+		fields := strings.Fields(ending)
+		if len(fields) > longest && len(fields) <= len(words) && strings.Join(words[:len(fields)], " ") == ending {
+			longest = len(fields)
+		}
+	}
+	return longest
+}
+
+// isDailyEnding reports whether word ends a 2-byte sentence
+func isDailyEnding(word string) bool {
+	return findIndex(dailyEndings, word) != -1
+}
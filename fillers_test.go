@@ -0,0 +1,104 @@
+package sentencecipher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFillerEndingsVary(t *testing.T) {
+	cipher, _ := NewCipher("filler-key")
+	pool := wordSet(cipher.fillerPool(workEndings))
+	seen := make(map[string]bool)
+	for i := 0; i < 40; i++ {
+		text := cipher.encodeRaw([]byte{42})
+		ending := strings.TrimSuffix(strings.SplitN(text, " ", 2)[1], ".")
+		if !pool[ending] {
+			t.Fatalf("ending %q is not in the key's pool", ending)
+		}
+		seen[ending] = true
+
+		decoded, err := cipher.decodeRaw(text)
+		if err != nil || len(decoded) != 1 || decoded[0] != 42 {
+			t.Fatalf("decodeRaw(%q) = %v, %v", text, decoded, err)
+		}
+	}
+	if len(seen) < 2 {
+		t.Errorf("40 encodings used only %d ending(s)", len(seen))
+	}
+}
+
+func TestFillerPoolsAreKeyed(t *testing.T) {
+	a, _ := NewCipher("filler-key-a")
+	b, _ := NewCipher("filler-key-b")
+	if strings.Join(a.fillerPool(dailyEndings), " ") == strings.Join(b.fillerPool(dailyEndings), " ") {
+		t.Error("different keys drew the same pool")
+	}
+}
+
+func TestDecodeAnyFillerEnding(t *testing.T) {
+	cipher, _ := NewCipher("filler-key")
+	name, verb := cipher.names[7], cipher.verbs[9]
+
+	// Every ending decodes, including the classic "works" and "daily" and
+	// endings outside this key's pool
+	for _, ending := range workEndings {
+		got, err := cipher.decodeSentence(strings.Fields(name + " " + ending))
+		if err != nil || len(got) != 1 || got[0] != 7 {
+			t.Errorf("%q: got %v, %v", ending, got, err)
+		}
+	}
+	for _, ending := range dailyEndings {
+		got, err := cipher.decodeSentence([]string{name, verb, ending})
+		if err != nil || len(got) != 2 || got[0] != 7 || got[1] != 9 {
+			t.Errorf("%q: got %v, %v", ending, got, err)
+		}
+	}
+}
+
+func TestFillerEndingsParseOneWay(t *testing.T) {
+	for _, name := range ThemeNames() {
+		rt, _ := lookupTheme(name)
+		names := wordSet(defaultNames)
+		for _, ending := range append(append([]string{}, workEndings...), dailyEndings...) {
+			for _, w := range strings.Fields(ending) {
+				if names[w] || rt.verbSet[w] || rt.objectSet[w] {
+					t.Errorf("theme %s: filler word %q is also a list word", name, w)
+				}
+			}
+		}
+	}
+	for _, ending := range workEndings {
+		fields := strings.Fields(ending)
+		if isDailyEnding(fields[len(fields)-1]) {
+			t.Errorf("work ending %q ends in a daily ending", ending)
+		}
+	}
+}
+
+func TestFillerEndingsCarryData(t *testing.T) {
+	cipher, _ := NewCipher("filler-key")
+	data := []byte("abcdefg") // one full sentence, then a 2-byte and a 1-byte one
+	sentences := splitSentences(cipher.encodeRaw(data))
+	if len(sentences) != 3 {
+		t.Fatalf("%d sentences, want 3: %q", len(sentences), sentences)
+	}
+	again := splitSentences(cipher.encodeRaw(data))
+	if sentences[1] != again[1] || sentences[2] != again[2] {
+		t.Errorf("short sentences differ between encodings: %q, %q", sentences[1:], again[1:])
+	}
+
+	// Another ending of the pool reads as other data (the lowest ending bit is
+	// padding here), one outside it not at all
+	words := strings.Fields(trimSentenceEnd(sentences[2]))
+	i := cipher.fillerIndex(workEndings, strings.Join(words[1:], " "))
+	if i < 0 {
+		t.Fatalf("ending of %q is not in the key's pool", sentences[2])
+	}
+	other := strings.Join(append(sentences[:2:2], words[0]+" "+cipher.workEnding(i^4)+"."), " ")
+	if decoded, err := cipher.decodeRaw(other); err == nil && string(decoded) == string(data) {
+		t.Errorf("ending %q decoded like %q", cipher.workEnding(i^4), words[1:])
+	}
+	if _, err := cipher.decodeRaw(strings.Join(append(sentences[:2:2], words[0]+" "+cipher.workEnding(-1)+"."), " ")); err == nil {
+		t.Error("expected error for an ending outside the key's pool")
+	}
+}
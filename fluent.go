@@ -0,0 +1,391 @@
+package sentencecipher
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fluent mode writes free-running office prose instead of fixed sentence
+// patterns. The payload is framed as
+//
+//	nonce (8 bytes) | version (1 byte) | length (uvarint) | payload
+//
+// where everything after the random nonce is masked with a keystream derived
+// from the key and the nonce, so the same payload never gives the same text
+// and two texts never share a keystream. The frame is read as the binary
+// fraction of an arithmetic code. The encoder arithmetic-decodes that
+// fraction with the language model (see ngram.go): each payload bit narrows
+// the interval, and the word whose interval contains the fraction comes next,
+// so words appear about as often as the model predicts. It stops at the end
+// of the first sentence after which every frame bit is settled. The decoder
+// replays the model over the words, arithmetic-encodes them and gets back the
+// frame, followed by random bits that it ignores.
+//
+// The version byte names the model the text was written with. The decoder
+// tries every released model, newest first, and keeps the one whose frame
+// names it.
+
+const (
+	arithBits    = 32
+	arithTop     = 1<<arithBits - 1
+	arithHalf    = 1 << (arithBits - 1)
+	arithQuarter = 1 << (arithBits - 2)
+)
+
+// fluentNonceSize is the length of the random nonce that starts every frame
+const fluentNonceSize = 8
+
+// maxFluentWordsPerBit bounds the text length in case the model stalls
+const maxFluentWordsPerBit = 16
+
+// SetFluentModel makes EncodeFluent write with the given language model
+// version (see LanguageModelVersions), or with the latest one for 0. Pinning
+// a version keeps the text readable for decoders without the newer models.
+func (c *Cipher) SetFluentModel(version int) error {
+	if version != 0 {
+		if _, err := lookupModel(version); err != nil {
+			return err
+		}
+	}
+	c.fluentModel = version
+	return nil
+}
+
+// fluentFrame picks a random nonce and follows it with version, the payload
+// length and the payload, masked with the keystream of the key and the nonce
+func (c *Cipher) fluentFrame(version int, data []byte) ([]byte, error) {
+	frame := make([]byte, fluentNonceSize, fluentNonceSize+1+binary.MaxVarintLen64+len(data))
+	if _, err := rand.Read(frame); err != nil {
+		return nil, fmt.Errorf("nonce generation failed: %w", err)
+	}
+	frame = append(frame, byte(version))
+	frame = binary.AppendUvarint(frame, uint64(len(data)))
+	frame = append(frame, data...)
+	c.maskFluent(frame[:fluentNonceSize], frame[fluentNonceSize:])
+	return frame, nil
+}
+
+// maskFluent XORs data in place with the fluent-mode keystream of the key and
+// nonce (SHA-256 in counter mode)
+func (c *Cipher) maskFluent(nonce, data []byte) {
+	seed := sha256.Sum256(append([]byte("sentence-cipher/fluent:"+c.key), nonce...))
+	var block [sha256.Size]byte
+	var counter [8]byte
+	for i := range data {
+		if i%sha256.Size == 0 {
+			binary.BigEndian.PutUint64(counter[:], uint64(i/sha256.Size))
+			block = sha256.Sum256(append(seed[:], counter[:]...))
+		}
+		data[i] ^= block[i%sha256.Size]
+	}
+}
+
+// arithEncoder turns a sequence of model choices into settled bits
+type arithEncoder struct {
+	low, high uint64
+	pending   int
+	bits      []byte // one bit per entry
+}
+
+func newArithEncoder() *arithEncoder {
+	return &arithEncoder{high: arithTop}
+}
+
+// encode narrows the interval to choice of freqs and emits the bits that are now settled
+func (e *arithEncoder) encode(freqs []int, choice int) {
+	cumLow, cumHigh, total := cumulative(freqs, choice)
+	e.low, e.high = narrow(e.low, e.high, cumLow, cumHigh, total)
+	for {
+		switch {
+		case e.high < arithHalf:
+			e.emit(0)
+		case e.low >= arithHalf:
+			e.emit(1)
+			e.low -= arithHalf
+			e.high -= arithHalf
+		case e.low >= arithQuarter && e.high < 3*arithQuarter:
+			e.pending++
+			e.low -= arithQuarter
+			e.high -= arithQuarter
+		default:
+			return
+		}
+		e.low <<= 1
+		e.high = e.high<<1 | 1
+	}
+}
+
+func (e *arithEncoder) emit(bit byte) {
+	e.bits = append(e.bits, bit)
+	for ; e.pending > 0; e.pending-- {
+		e.bits = append(e.bits, 1-bit)
+	}
+}
+
+// arithDecoder turns a bit stream into model choices
+type arithDecoder struct {
+	low, high, value uint64
+	next             func() uint64
+}
+
+func newArithDecoder(next func() uint64) *arithDecoder {
+	d := &arithDecoder{high: arithTop, next: next}
+	for i := 0; i < arithBits; i++ {
+		d.value = d.value<<1 | next()
+	}
+	return d
+}
+
+// decode returns the choice of freqs whose interval holds the stream and narrows to it
+func (d *arithDecoder) decode(freqs []int) int {
+	total := 0
+	for _, f := range freqs {
+		total += f
+	}
+	scaled := ((d.value-d.low+1)*uint64(total) - 1) / (d.high - d.low + 1)
+	choice, cum := 0, uint64(freqs[0])
+	for cum <= scaled {
+		choice++
+		cum += uint64(freqs[choice])
+	}
+
+	cumLow, cumHigh, _ := cumulative(freqs, choice)
+	d.low, d.high = narrow(d.low, d.high, cumLow, cumHigh, uint64(total))
+	for {
+		switch {
+		case d.high < arithHalf:
+		case d.low >= arithHalf:
+			d.low -= arithHalf
+			d.high -= arithHalf
+			d.value -= arithHalf
+		case d.low >= arithQuarter && d.high < 3*arithQuarter:
+			d.low -= arithQuarter
+			d.high -= arithQuarter
+			d.value -= arithQuarter
+		default:
+			return choice
+		}
+		d.low <<= 1
+		d.high = d.high<<1 | 1
+		d.value = d.value<<1 | d.next()
+	}
+}
+
+// cumulative returns the frequency interval of choice and the total of freqs
+func cumulative(freqs []int, choice int) (cumLow, cumHigh, total uint64) {
+	for i, f := range freqs {
+		if i < choice {
+			cumLow += uint64(f)
+		}
+		total += uint64(f)
+	}
+	return cumLow, cumLow + uint64(freqs[choice]), total
+}
+
+// narrow shrinks [low, high] to the sub-interval [cumLow, cumHigh) of total
+func narrow(low, high, cumLow, cumHigh, total uint64) (uint64, uint64) {
+	width := high - low + 1
+	return low + width*cumLow/total, low + width*cumHigh/total - 1
+}
+
+// encodeFluentRaw writes data as fluent text without compression (internal use)
+func (c *Cipher) encodeFluentRaw(data []byte) (string, error) {
+	version := c.fluentModel
+	if version == 0 {
+		version = latestModelVersion
+	}
+	model, err := lookupModel(version)
+	if err != nil {
+		return "", err
+	}
+	frame, err := c.fluentFrame(version, data)
+	if err != nil {
+		return "", err
+	}
+	frameBits := 8 * len(frame)
+
+	// The frame, then random bits so the last sentence varies
+	var randErr error
+	pos, random := 0, make([]byte, 0, 32)
+	next := func() uint64 {
+		i := pos
+		pos++
+		if i < frameBits {
+			return uint64(frame[i/8]>>(7-i%8)) & 1
+		}
+		i -= frameBits
+		if i/8 == len(random) {
+			var b [1]byte
+			if _, err := rand.Read(b[:]); err != nil {
+				randErr = err
+			}
+			random = append(random, b[0])
+		}
+		return uint64(random[i/8]>>(7-i%8)) & 1
+	}
+
+	dec := newArithDecoder(next)
+	enc := newArithEncoder()
+	var sentences [][]string
+	var sentence []string
+	ctx := model.start()
+	for n := 0; len(enc.bits) < frameBits || len(sentence) > 0; n++ {
+		if n > maxFluentWordsPerBit*frameBits {
+			return "", errors.New("language model stalled")
+		}
+		words, freqs := model.distribution(ctx)
+		choice := dec.decode(freqs)
+		enc.encode(freqs, choice)
+
+		word := words[choice]
+		if model.words[word] == sentenceEndWord {
+			sentences = append(sentences, sentence)
+			sentence = nil
+		} else {
+			sentence = append(sentence, model.words[word])
+		}
+		ctx = model.advance(ctx, word)
+	}
+	if randErr != nil {
+		return "", fmt.Errorf("random generation failed: %w", randErr)
+	}
+
+	lines := make([]string, len(sentences))
+	for i, words := range sentences {
+		for j, w := range words {
+			if j == 0 || w == "i" {
+				words[j] = capitalize(w)
+			}
+		}
+		lines[i] = strings.Join(words, " ") + "."
+	}
+	return strings.Join(lines, " "), nil
+}
+
+// fluentTokens splits fluent text into lowercase words and sentence ends
+func fluentTokens(text string) []string {
+	var tokens []string
+	for _, field := range strings.Fields(strings.ToLower(normalizeText(text))) {
+		end := strings.HasSuffix(strings.TrimRight(field, `"')`), ".")
+		if w := strings.Trim(field, `.,;:!?"'()`); w != "" {
+			tokens = append(tokens, w)
+		}
+		if end {
+			tokens = append(tokens, sentenceEndWord)
+		}
+	}
+	return tokens
+}
+
+// decodeFluentRaw reads data back from fluent text without decompression (internal use)
+func (c *Cipher) decodeFluentRaw(encoded string) ([]byte, error) {
+	tokens := fluentTokens(encoded)
+	versions := LanguageModelVersions()
+	var firstErr error
+	for i := len(versions) - 1; i >= 0; i-- {
+		data, err := c.decodeFluentModel(languageModels[versions[i]], tokens)
+		if err == nil {
+			return data, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// decodeFluentModel replays model over tokens and unframes the bits
+func (c *Cipher) decodeFluentModel(model *ngramModel, tokens []string) ([]byte, error) {
+	frame, err := replayFluent(model, tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(frame) <= fluentNonceSize {
+		return nil, fmt.Errorf("text was not written with language model %d", model.version)
+	}
+	c.maskFluent(frame[:fluentNonceSize], frame[fluentNonceSize:])
+	frame = frame[fluentNonceSize:]
+	if int(frame[0]) != model.version {
+		return nil, fmt.Errorf("text was not written with language model %d", model.version)
+	}
+	length, n := binary.Uvarint(frame[1:])
+	if n <= 0 || length > uint64(len(frame)-1-n) {
+		return nil, errors.New("fluent text is truncated")
+	}
+	return frame[1+n : 1+n+int(length)], nil
+}
+
+// replayFluent arithmetic-encodes the model's choices of tokens back into the
+// (still masked) frame and the whole bytes that follow it
+func replayFluent(model *ngramModel, tokens []string) ([]byte, error) {
+	enc := newArithEncoder()
+	ctx := model.start()
+	for _, token := range tokens {
+		word, ok := model.index[token]
+		if !ok {
+			return nil, fmt.Errorf("language model %d: unknown word %q", model.version, token)
+		}
+		words, freqs := model.distribution(ctx)
+		choice := -1
+		for i, w := range words {
+			if w == word {
+				choice = i
+			}
+		}
+		if choice < 0 {
+			return nil, fmt.Errorf("language model %d: unexpected word %q", model.version, token)
+		}
+		enc.encode(freqs, choice)
+		ctx = model.advance(ctx, word)
+	}
+
+	frame := make([]byte, len(enc.bits)/8)
+	for i := range frame {
+		for _, bit := range enc.bits[8*i : 8*i+8] {
+			frame[i] = frame[i]<<1 | bit
+		}
+	}
+	return frame, nil
+}
+
+// EncodeFluent compresses data then writes it as fluent prose chosen by the language model
+func (c *Cipher) EncodeFluent(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return "", fmt.Errorf("compression failed: %w", err)
+	}
+	padded, err := c.padPayload(compressed)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeFluentRaw(padded)
+}
+
+// DecodeFluent decodes fluent prose then decompresses
+func (c *Cipher) DecodeFluent(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return []byte{}, nil
+	}
+	compressed, err := c.decodeFluentRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return c.openPayload(compressed)
+}
+
+// EncodeFluent compresses then writes fluent prose (package-level)
+func EncodeFluent(data []byte) (string, error) {
+	return NewDefaultCipher().EncodeFluent(data)
+}
+
+// DecodeFluent decodes fluent prose then decompresses (package-level)
+func DecodeFluent(encoded string) ([]byte, error) {
+	return NewDefaultCipher().DecodeFluent(encoded)
+}
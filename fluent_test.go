@@ -0,0 +1,137 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestFluentRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, key := range []string{"", "fluent-key"} {
+		cipher := NewDefaultCipher()
+		if key != "" {
+			cipher, _ = NewCipher(key)
+		}
+		for n := 1; n < 200; n += 13 {
+			data := make([]byte, n)
+			rng.Read(data)
+			text, err := cipher.encodeFluentRaw(data)
+			if err != nil {
+				t.Fatalf("encodeFluentRaw error: %v", err)
+			}
+			decoded, err := cipher.decodeFluentRaw(text)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Fatalf("%d bytes: decodeFluentRaw = %x, %v\n%s", n, decoded, err, text)
+			}
+		}
+	}
+
+	input := []byte("Fluent text follows the language model word by word")
+	text, err := EncodeFluent(input)
+	if err != nil {
+		t.Fatalf("EncodeFluent error: %v", err)
+	}
+	if !strings.HasSuffix(text, ".") {
+		t.Errorf("text does not end a sentence: %s", text)
+	}
+	decoded, err := DecodeFluent(text)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeFluent = %q, %v", decoded, err)
+	}
+}
+
+func TestFluentSurvivesEmailDamage(t *testing.T) {
+	cipher, _ := NewCipher("fluent-key")
+	input := []byte("Quoted and wrapped by a mail client")
+	text, _ := cipher.EncodeFluent(input)
+
+	// Wrap at about 60 columns and quote every line
+	var lines []string
+	line := ""
+	for _, w := range strings.Fields(text) {
+		if len(line)+len(w) > 60 {
+			lines = append(lines, "> "+line)
+			line = ""
+		}
+		line = strings.TrimSpace(line + " " + w)
+	}
+	lines = append(lines, "> "+line)
+
+	decoded, err := cipher.DecodeFluent(strings.Join(lines, "\r\n"))
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeFluent = %q, %v", decoded, err)
+	}
+
+	other, _ := NewCipher("other-key")
+	if decoded, err := other.DecodeFluent(text); err == nil && bytes.Equal(decoded, input) {
+		t.Error("wrong key decoded the message")
+	}
+	if _, err := cipher.DecodeFluent(text + " Quarterly synergy."); err == nil {
+		t.Error("expected error for a word outside the model")
+	}
+}
+
+func TestFluentModelVersions(t *testing.T) {
+	if versions := LanguageModelVersions(); len(versions) == 0 || versions[len(versions)-1] != latestModelVersion {
+		t.Errorf("LanguageModelVersions() = %v, latest %d", versions, latestModelVersion)
+	}
+	cipher, _ := NewCipher("golden-key")
+	if err := cipher.SetFluentModel(99); err == nil {
+		t.Error("expected error for an unknown model version")
+	}
+	if err := cipher.SetFluentModel(1); err != nil {
+		t.Fatalf("SetFluentModel error: %v", err)
+	}
+
+	// Written with model 1; released models must keep decoding it
+	golden := "We will share the meeting has been sent to finance. We are slightly above the report is ready. " +
+		"We agreed to the meeting to the contract. The room is ready. Just a reminder that the new team is " +
+		"booked the timeline for the budget. The numbers together so quickly. If you have any questions. " +
+		"We have moved the updated slides from last month. We have finished the issue with the launch. " +
+		"The project is behind schedule by friday. Let me know if the proposal. Please add this to the launch. " +
+		"Before the team. We have moved the slides with the report is due on thursday. The new hire two more " +
+		"changes before the shared folder."
+	decoded, err := cipher.DecodeFluent(golden)
+	if err != nil || string(decoded) != "written with model one" {
+		t.Errorf("DecodeFluent = %q, %v", decoded, err)
+	}
+}
+
+func TestFluentNonce(t *testing.T) {
+	cipher, _ := NewCipher("fluent-key")
+	model, _ := lookupModel(latestModelVersion)
+
+	// A short first sentence can repeat by chance, a few in a row cannot
+	input := []byte("same payload, fresh nonce")
+	a, _ := cipher.encodeFluentRaw(input)
+	differ := false
+	for i := 0; i < 3 && !differ; i++ {
+		b, _ := cipher.encodeFluentRaw(input)
+		differ = splitSentences(a)[0] != splitSentences(b)[0]
+	}
+	if !differ {
+		t.Errorf("encodings of the same payload share their first sentence: %s", a)
+	}
+
+	// Two frames must not share a keystream: their XOR is not the payloads' XOR
+	x, y := []byte("first payload"), []byte("other payload")
+	textX, _ := cipher.encodeFluentRaw(x)
+	textY, _ := cipher.encodeFluentRaw(y)
+	frameX, err := replayFluent(model, fluentTokens(textX))
+	if err != nil {
+		t.Fatalf("replayFluent error: %v", err)
+	}
+	frameY, _ := replayFluent(model, fluentTokens(textY))
+	payload := fluentNonceSize + 2
+	same := true
+	for i := range x {
+		if frameX[payload+i]^frameY[payload+i] != x[i]^y[i] {
+			same = false
+		}
+	}
+	if same {
+		t.Error("frames XOR to the payloads' XOR: the keystream is reused")
+	}
+}
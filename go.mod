@@ -5,3 +5,10 @@ go 1.19
 require github.com/gopherjs/gopherjs v1.19.0-beta2
 
 require github.com/andybalholm/brotli v1.1.0
+
+require (
+	golang.org/x/crypto v0.9.0
+	golang.org/x/term v0.10.0
+)
+
+require golang.org/x/sys v0.10.0 // indirect
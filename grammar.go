@@ -0,0 +1,488 @@
+package sentencecipher
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Grammar files describe a cover language without Go code:
+//
+//	# Office cover
+//	grammar office
+//
+//	<sentence> ::= <short> | <full>
+//	<short>    ::= $subject $work "."
+//	             | $subject $verb $daily "."
+//	<full>     ::= $subject $verb $indirect $object "."
+//	             | $subject $verb "the" $object <prep> $indirect "."
+//	<prep>     ::= "for" | "with"
+//
+// The first rule is the start symbol. An alternative is a sequence of
+// nonterminals, word-list references ($subject, $verb, $base, $participle,
+// $object, $indirect, $work, $daily; see Slot and fillers.go) and quoted
+// literals, and ends with "." or "?". Lines starting with "|" continue the
+// rule above and "#" starts a comment.
+//
+// Compiling expands the start symbol into every sentence shape it generates.
+// Alternatives are weighted by capacity: a shape carries as many bytes as it
+// has data slots, short shapes end the message, and the choice among the
+// full (3-byte) shapes, a power of two of them, carries extra bits.
+
+const maxGrammarShapes = 1024
+
+// grammarRefs maps word-list references to template slots
+var grammarRefs = map[string]string{
+	"$subject": "S", "$verb": "V", "$base": "B", "$participle": "P",
+	"$object": "O", "$indirect": "I", "$work": "W", "$daily": "D",
+}
+
+// Grammar is a compiled grammar file. SetGrammar makes a cipher encode and
+// decode its cover language.
+type Grammar struct {
+	Name   string
+	shapes []*templatePattern
+	set    *patternSet
+}
+
+// LoadGrammar reads and compiles a grammar file
+func LoadGrammar(path string) (*Grammar, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading grammar: %w", err)
+	}
+	return ParseGrammar(string(src))
+}
+
+// ParseGrammar compiles the text of a grammar file. It fails if the grammar
+// is ambiguous: if two shapes can produce the same sentence or a literal is
+// also a word of some word list.
+func ParseGrammar(src string) (*Grammar, error) {
+	g := &Grammar{}
+	rules := make(map[string][][]string)
+	var order []string
+	var last string
+
+	for n, line := range strings.Split(src, "\n") {
+		tokens, err := grammarTokens(line)
+		if err != nil {
+			return nil, fmt.Errorf("grammar line %d: %w", n+1, err)
+		}
+		switch {
+		case len(tokens) == 0:
+			continue
+		case tokens[0] == "grammar":
+			if len(tokens) != 2 {
+				return nil, fmt.Errorf("grammar line %d: want \"grammar NAME\"", n+1)
+			}
+			g.Name = tokens[1]
+		case tokens[0] == "|":
+			if last == "" {
+				return nil, fmt.Errorf("grammar line %d: alternative outside a rule", n+1)
+			}
+			rules[last] = append(rules[last], splitAlternatives(tokens[1:])...)
+		case len(tokens) >= 2 && isNonterminal(tokens[0]) && tokens[1] == "::=":
+			last = tokens[0]
+			if _, ok := rules[last]; ok {
+				return nil, fmt.Errorf("grammar line %d: rule %s defined twice", n+1, last)
+			}
+			order = append(order, last)
+			rules[last] = splitAlternatives(tokens[2:])
+		default:
+			return nil, fmt.Errorf("grammar line %d: want \"<name> ::= ...\"", n+1)
+		}
+	}
+	if len(order) == 0 {
+		return nil, errors.New("grammar has no rules")
+	}
+
+	sequences, err := expandGrammar(rules, order[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	var list []Pattern
+	for _, seq := range sequences {
+		pat, err := grammarShape(seq)
+		if err != nil {
+			return nil, err
+		}
+		g.shapes = append(g.shapes, pat)
+		list = append(list, pat)
+	}
+	if g.set, err = groupPatterns(list); err != nil {
+		return nil, fmt.Errorf("grammar: %w", err)
+	}
+	if err := g.checkAmbiguity(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// grammarTokens splits a line into tokens, keeping quoted literals (with
+// their quotes) together and dropping comments
+func grammarTokens(line string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '#':
+			return tokens, nil
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '"':
+			end := strings.IndexByte(line[i+1:], '"')
+			if end == -1 {
+				return nil, errors.New("unterminated literal")
+			}
+			tokens = append(tokens, line[i:i+end+2])
+			i += end + 2
+		default:
+			end := strings.IndexAny(line[i:], " \t\r\"#")
+			if end == -1 {
+				end = len(line) - i
+			}
+			tokens = append(tokens, line[i:i+end])
+			i += end
+		}
+	}
+	return tokens, nil
+}
+
+func isNonterminal(tok string) bool {
+	return len(tok) > 2 && tok[0] == '<' && tok[len(tok)-1] == '>'
+}
+
+func splitAlternatives(tokens []string) [][]string {
+	alts := [][]string{nil}
+	for _, tok := range tokens {
+		if tok == "|" {
+			alts = append(alts, nil)
+			continue
+		}
+		alts[len(alts)-1] = append(alts[len(alts)-1], tok)
+	}
+	return alts
+}
+
+// expandGrammar returns every token sequence symbol generates, as template
+// tokens and "." or "?"
+func expandGrammar(rules map[string][][]string, symbol string, stack []string) ([][]string, error) {
+	for _, s := range stack {
+		if s == symbol {
+			return nil, fmt.Errorf("grammar: rule %s is recursive", symbol)
+		}
+	}
+	alts, ok := rules[symbol]
+	if !ok {
+		return nil, fmt.Errorf("grammar: rule %s is not defined", symbol)
+	}
+	stack = append(stack, symbol)
+
+	var out [][]string
+	for _, alt := range alts {
+		if len(alt) == 0 {
+			return nil, fmt.Errorf("grammar: empty alternative in %s", symbol)
+		}
+		seqs := [][]string{nil}
+		for _, tok := range alt {
+			var parts [][]string
+			switch {
+			case isNonterminal(tok):
+				sub, err := expandGrammar(rules, tok, stack)
+				if err != nil {
+					return nil, err
+				}
+				parts = sub
+			case strings.HasPrefix(tok, "$"):
+				slot, ok := grammarRefs[tok]
+				if !ok {
+					return nil, fmt.Errorf("grammar: unknown word list %s in %s", tok, symbol)
+				}
+				parts = [][]string{{slot}}
+			case strings.HasPrefix(tok, `"`):
+				words, err := grammarLiteral(tok)
+				if err != nil {
+					return nil, fmt.Errorf("grammar: %s in %s", err, symbol)
+				}
+				parts = [][]string{words}
+			default:
+				return nil, fmt.Errorf("grammar: unexpected %q in %s", tok, symbol)
+			}
+
+			var next [][]string
+			for _, seq := range seqs {
+				for _, part := range parts {
+					next = append(next, append(append([]string{}, seq...), part...))
+				}
+			}
+			if len(next) > maxGrammarShapes {
+				return nil, fmt.Errorf("grammar: more than %d sentence shapes", maxGrammarShapes)
+			}
+			seqs = next
+		}
+		out = append(out, seqs...)
+		if len(out) > maxGrammarShapes {
+			return nil, fmt.Errorf("grammar: more than %d sentence shapes", maxGrammarShapes)
+		}
+	}
+	return out, nil
+}
+
+// grammarLiteral splits a quoted literal into words; "." and "?" stand alone
+func grammarLiteral(tok string) ([]string, error) {
+	text := tok[1 : len(tok)-1]
+	if text == "." || text == "?" {
+		return []string{text}, nil
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return nil, errors.New("empty literal")
+	}
+	for _, w := range words {
+		if w != strings.ToLower(w) || strings.ContainsAny(w, ".?,") {
+			return nil, fmt.Errorf("invalid literal %s: words must be lowercase without punctuation", tok)
+		}
+	}
+	return words, nil
+}
+
+// grammarShape turns an expanded sequence into a template pattern
+func grammarShape(seq []string) (*templatePattern, error) {
+	text := strings.Join(seq, " ")
+	end := seq[len(seq)-1]
+	if end != "." && end != "?" {
+		return nil, fmt.Errorf("grammar: sentence %q must end with \".\" or \"?\"", text)
+	}
+	pat := &templatePattern{tokens: seq[:len(seq)-1], question: end == "?"}
+	for _, tok := range pat.tokens {
+		if tok == "." || tok == "?" {
+			return nil, fmt.Errorf("grammar: sentence %q has punctuation before its end", text)
+		}
+	}
+	if len(pat.tokens) == 0 {
+		return nil, fmt.Errorf("grammar: empty sentence %q", text)
+	}
+	if err := checkPatternSlots(pat); err != nil {
+		return nil, fmt.Errorf("grammar: sentence %q: %w", text, err)
+	}
+	return pat, nil
+}
+
+// grammarClasses returns the words each template slot may be, over all
+// registered themes, plus every work-ending word under "W"
+func grammarClasses() map[string]map[string]bool {
+	classes := map[string]map[string]bool{
+		"S": wordSet(defaultNames), "D": wordSet(dailyEndings),
+		"V": {}, "B": {}, "P": {}, "O": {}, "W": {},
+	}
+	classes["I"] = classes["S"]
+	verbLists := [][]string{spokenVerbs()}
+	for _, name := range ThemeNames() {
+		rt, _ := lookupTheme(name)
+		for _, n := range rt.Names {
+			classes["S"][n] = true
+		}
+		verbLists = append(verbLists, rt.Verbs)
+		for _, o := range rt.Objects {
+			classes["O"][o] = true
+		}
+	}
+	for _, verbs := range verbLists {
+		for _, v := range verbs {
+			classes["V"][v] = true
+			classes["B"][verbBaseForm(v)] = true
+			classes["P"][verbParticiple(v)] = true
+		}
+	}
+	for _, ending := range workEndings {
+		for _, w := range strings.Fields(ending) {
+			classes["W"][w] = true
+		}
+	}
+	return classes
+}
+
+// spokenVerbs returns the verbs SetSpokenVocabulary swaps in
+func spokenVerbs() []string {
+	c := NewDefaultCipher()
+	c.SetSpokenVocabulary(true)
+	return c.verbs
+}
+
+// checkAmbiguity rejects grammars whose shapes can be confused (see
+// checkShapes) or do not parse back with the built-in word lists
+func (g *Grammar) checkAmbiguity() error {
+	if err := checkShapes(g.shapes); err != nil {
+		return fmt.Errorf("grammar is ambiguous: %w", err)
+	}
+
+	// Every shape must also parse back with the built-in word lists
+	for _, w := range append([]*Cipher{NewDefaultCipher()}, themedCiphers()...) {
+		if err := g.set.verify(w); err != nil {
+			return fmt.Errorf("grammar: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkShapes rejects literals that are also list words and pairs of shapes
+// that can produce the same words (whatever their final punctuation)
+func checkShapes(shapes []*templatePattern) error {
+	classes := grammarClasses()
+	for _, pat := range shapes {
+		for _, lit := range pat.literals() {
+			for _, slot := range []string{"S", "V", "B", "P", "O"} {
+				if classes[slot][lit] {
+					return fmt.Errorf("literal %q is also a word for %s", lit, slotRef(slot))
+				}
+			}
+		}
+	}
+
+	// Work endings span several words: compare every ending on its own
+	var expanded [][]string
+	var owner []int
+	for i, pat := range shapes {
+		for _, seq := range expandWorkEndings(pat.tokens) {
+			expanded = append(expanded, seq)
+			owner = append(owner, i)
+		}
+	}
+	for a := range expanded {
+		for b := a + 1; b < len(expanded); b++ {
+			if owner[a] != owner[b] && shapesOverlap(classes, expanded[a], expanded[b]) {
+				return fmt.Errorf("%q and %q can produce the same sentence",
+					shapes[owner[a]].template(), shapes[owner[b]].template())
+			}
+		}
+	}
+	return nil
+}
+
+func themedCiphers() []*Cipher {
+	var ciphers []*Cipher
+	for _, name := range ThemeNames() {
+		ciphers = append(ciphers, NewThemedCipher("", name))
+	}
+	return ciphers
+}
+
+// expandWorkEndings replaces a W token by the words of each work ending
+func expandWorkEndings(tokens []string) [][]string {
+	seqs := [][]string{nil}
+	for _, tok := range tokens {
+		parts := [][]string{{tok}}
+		if tok == "W" {
+			parts = nil
+			for _, ending := range workEndings {
+				parts = append(parts, strings.Fields(ending))
+			}
+		}
+		var next [][]string
+		for _, seq := range seqs {
+			for _, part := range parts {
+				next = append(next, append(append([]string{}, seq...), part...))
+			}
+		}
+		seqs = next
+	}
+	return seqs
+}
+
+// shapesOverlap reports whether two token sequences without work endings can
+// produce the same words
+func shapesOverlap(classes map[string]map[string]bool, a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !tokensOverlap(classes, a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func tokensOverlap(classes map[string]map[string]bool, a, b string) bool {
+	wa, wb := classes[a], classes[b]
+	switch {
+	case !isTemplateSlot(a) && !isTemplateSlot(b):
+		return a == b
+	case !isTemplateSlot(a):
+		return wb[a]
+	case !isTemplateSlot(b):
+		return wa[b]
+	}
+	if a == b {
+		return true
+	}
+	for w := range wa {
+		if wb[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// slotRef names a template slot as grammar files write it
+func slotRef(slot string) string {
+	for ref, s := range grammarRefs {
+		if s == slot {
+			return ref
+		}
+	}
+	return slot
+}
+
+// template writes the pattern back as a template
+func (pat *templatePattern) template() string {
+	if pat.question {
+		return strings.Join(pat.tokens, " ") + "?"
+	}
+	return strings.Join(pat.tokens, " ") + "."
+}
+
+// Templates returns the sentence shapes the grammar generates, as templates
+// for NewTemplatePattern
+func (g *Grammar) Templates() []string {
+	var out []string
+	for _, pat := range g.shapes {
+		out = append(out, strings.TrimSuffix(pat.template(), "."))
+	}
+	return out
+}
+
+// Bits returns the bits carried by the choice of full sentence shape
+func (g *Grammar) Bits() int {
+	return g.set.bits
+}
+
+// SetGrammar makes the cipher write and read the grammar's cover language.
+// Both sides must load the same grammar. It is checked for ambiguity again,
+// as themes registered since it was parsed may share words with its literals,
+// and themes registered afterwards may not use them.
+func (c *Cipher) SetGrammar(g *Grammar) error {
+	if err := g.checkAmbiguity(); err != nil {
+		return err
+	}
+	if err := g.set.verify(c); err != nil {
+		return fmt.Errorf("grammar: %w", err)
+	}
+	grammarLiterals.Lock()
+	for _, pat := range g.shapes {
+		for _, lit := range pat.literals() {
+			grammarLiterals.words[lit] = true
+		}
+	}
+	grammarLiterals.Unlock()
+	c.patterns = g.set
+	return nil
+}
+
+// grammarLiterals holds the literals of every grammar set on a cipher, which
+// themes registered afterwards may not use (see isPatternLiteral)
+var grammarLiterals = struct {
+	sync.RWMutex
+	words map[string]bool
+}{words: make(map[string]bool)}
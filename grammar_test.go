@@ -0,0 +1,181 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// builtinGrammar describes the built-in patterns
+const builtinGrammar = `
+# The built-in sentence patterns
+grammar builtin
+
+<sentence> ::= $subject $work "."
+             | $subject $verb $daily "."
+             | <statement> "." | <question> "?"
+<statement> ::= $subject $verb $indirect $object
+             | $subject $verb "the" $object <prep> $indirect
+             | $subject "and" $indirect $base "the" $object
+             | "the" $object "will be" $participle "by" $subject <passive> $indirect
+<passive>  ::= "for" | "and"
+<prep>     ::= "for" | "with"
+<question> ::= "can" $subject $base "the" $object "for" $indirect
+             | "will" $subject $base $indirect "the" $object
+`
+
+func TestGrammarMatchesBuiltinPatterns(t *testing.T) {
+	g, err := ParseGrammar(builtinGrammar)
+	if err != nil {
+		t.Fatalf("ParseGrammar error: %v", err)
+	}
+	if g.Name != "builtin" || g.Bits() != 3 {
+		t.Errorf("grammar %q carries %d bits, want builtin with 3", g.Name, g.Bits())
+	}
+
+	want := []string{"S W", "S V D"}
+	for _, b := range builtinPatterns[2:] {
+		want = append(want, b.template)
+	}
+	if got := g.Templates(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Templates() = %q, want %q", got, want)
+	}
+
+	// A grammar cipher and a built-in one read each other's messages
+	sender, _ := NewCipher("grammar-key")
+	receiver, _ := NewCipher("grammar-key")
+	if err := receiver.SetGrammar(g); err != nil {
+		t.Fatalf("SetGrammar error: %v", err)
+	}
+	input := []byte("grammar files describe the same language")
+	text, _ := sender.Encode(input)
+	decoded, err := receiver.Decode(text)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("Decode = %q, %v", decoded, err)
+	}
+}
+
+func TestGrammarCoverLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.grammar")
+	src := `
+grammar status
+<sentence> ::= $subject $work "."
+             | $subject $verb $daily "."
+             | <opener> $subject $verb "the" $object <prep> $indirect "."
+<opener>   ::= "today" | "yesterday"
+<prep>     ::= "for" | "with"
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := LoadGrammar(path)
+	if err != nil {
+		t.Fatalf("LoadGrammar error: %v", err)
+	}
+	if g.Bits() != 2 {
+		t.Errorf("Bits() = %d, want 2", g.Bits())
+	}
+
+	cipher, _ := NewCipher("grammar-key")
+	if err := cipher.SetGrammar(g); err != nil {
+		t.Fatalf("SetGrammar error: %v", err)
+	}
+	input := []byte("Status updates in a language of our own")
+	for _, encode := range []func([]byte) (string, error){cipher.Encode, cipher.EncodeNatural, cipher.EncodeMarkdown} {
+		text, err := encode(input)
+		if err != nil {
+			t.Fatalf("encode error: %v", err)
+		}
+		var decoded []byte
+		switch {
+		case strings.HasPrefix(text, "# "):
+			decoded, err = cipher.DecodeMarkdown(text)
+		case strings.HasPrefix(text, "Subject:"):
+			decoded, err = cipher.DecodeNatural(text)
+		default:
+			if !strings.Contains(strings.ToLower(text), "yesterday ") {
+				t.Errorf("grammar shapes unused: %s", text)
+			}
+			decoded, err = cipher.Decode(text)
+		}
+		if err != nil || !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, %v from:\n%s", decoded, err, text)
+		}
+	}
+}
+
+func TestGrammarErrors(t *testing.T) {
+	short := "<sentence> ::= $subject $work \".\" | $subject $verb $daily \".\" | "
+	for name, src := range map[string]string{
+		"no rules":        "# nothing\n",
+		"bad line":        "sentence = $subject",
+		"undefined":       short + "<full>",
+		"recursive":       short + "<full>\n<full> ::= $subject $verb $indirect $object <full>",
+		"unknown list":    short + `$subject $verb $indirect $thing "."`,
+		"no end":          short + `$subject $verb $indirect $object`,
+		"missing slot":    short + `$subject $verb $object "."`,
+		"not power of 2":  short + `$subject $verb $indirect $object "." | $subject $verb "the" $object "for" $indirect "." | $subject $verb "the" $object "with" $indirect "."`,
+		"literal is word": short + `$subject $verb "reports" $indirect $object "."`,
+		"same sentences":  short + `$subject $verb $indirect $object "." | $subject $verb $indirect $object "?"`,
+		"overlapping":     short + `$subject $verb $indirect $object "." | $indirect $verb $subject $object "."`,
+		"uppercase":       short + `$subject $verb "The" $object "for" $indirect "."`,
+		"unterminated":    short + `$subject $verb "the $object`,
+	} {
+		if _, err := ParseGrammar(src); err == nil {
+			t.Errorf("%s: ParseGrammar succeeded", name)
+		}
+	}
+}
+
+func TestSetGrammarRechecksThemes(t *testing.T) {
+	short := "<sentence> ::= $subject $work \".\" | $subject $verb $daily \".\" | "
+	g, err := ParseGrammar(short + `$subject $verb $indirect $object "." | $subject $verb "latchverb9" $indirect $object "."`)
+	if err != nil {
+		t.Fatalf("ParseGrammar error: %v", err)
+	}
+
+	// A theme registered later has the grammar's literal among its verbs
+	if err := RegisterTheme(testTheme("latch")); err != nil {
+		t.Fatalf("RegisterTheme error: %v", err)
+	}
+	t.Cleanup(func() { unregisterTheme("latch") })
+	cipher, _ := NewCipher("grammar-key")
+	if err := cipher.SetGrammar(g); err == nil {
+		t.Error("SetGrammar accepted a grammar whose literal is now a verb")
+	}
+}
+
+func TestGrammarChecksThemeNames(t *testing.T) {
+	theme := testTheme("roster")
+	for i := 0; i < 256; i++ {
+		theme.Names = append(theme.Names, fmt.Sprintf("rostername%d", i))
+	}
+	if err := RegisterTheme(theme); err != nil {
+		t.Fatalf("RegisterTheme error: %v", err)
+	}
+	t.Cleanup(func() { unregisterTheme("roster") })
+
+	short := "<sentence> ::= $subject $work \".\" | $subject $verb $daily \".\" | "
+	if _, err := ParseGrammar(short + `$subject $verb "rostername4" $indirect $object "."`); err == nil {
+		t.Error("ParseGrammar accepted a literal that is a theme's name")
+	}
+}
+
+func TestThemeAfterGrammarRejectsLiterals(t *testing.T) {
+	short := "<sentence> ::= $subject $work \".\" | $subject $verb $daily \".\" | "
+	g, err := ParseGrammar(short + `$subject $verb $indirect $object "." | $subject $verb "laterverb5" $indirect $object "."`)
+	if err != nil {
+		t.Fatalf("ParseGrammar error: %v", err)
+	}
+	cipher, _ := NewCipher("grammar-key")
+	if err := cipher.SetGrammar(g); err != nil {
+		t.Fatalf("SetGrammar error: %v", err)
+	}
+	if err := RegisterTheme(testTheme("later")); err == nil {
+		unregisterTheme("later")
+		t.Error("RegisterTheme accepted a verb that is a literal of a grammar in use")
+	}
+}
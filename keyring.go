@@ -0,0 +1,315 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Keyring messages start with a 4-byte key-ID hint before the compressed data:
+// - Bytes 0-1: random nonce
+// - Bytes 2-3: HMAC-SHA256(key, "key id" || nonce)
+//
+// The hint lets Keyring.Decode skip keys that cannot match without revealing
+// which key was used: it is only readable with the key, and the nonce keeps it
+// from repeating across messages.
+const (
+	keyHintNonceSize = 2
+	keyHintTagSize   = 2
+	keyHintSize      = keyHintNonceSize + keyHintTagSize
+)
+
+// Keyring file layout: magic || salt(16) || nonce(12) || ChaCha20-Poly1305(JSON)
+// with the file key derived from the passphrase by scrypt.
+var keyringMagic = []byte("SCKR1")
+
+const keyringSaltSize = 16
+
+// ErrNoMatchingKey is returned when no key in the keyring decodes a message
+var ErrNoMatchingKey = errors.New("no key in the keyring decodes this message")
+
+// Keyring holds named symmetric keys and X25519 identities
+type Keyring struct {
+	keys       map[string]string
+	identities map[string]*PrivateKey
+}
+
+// keyringFile is the JSON stored (encrypted) in a keyring file
+type keyringFile struct {
+	Keys       map[string]string `json:"keys"`
+	Identities map[string]string `json:"identities,omitempty"`
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys:       make(map[string]string),
+		identities: make(map[string]*PrivateKey),
+	}
+}
+
+// Add stores a symmetric key under name, replacing any existing entry
+func (kr *Keyring) Add(name, key string) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if key == "" {
+		return errors.New("key is required")
+	}
+	kr.keys[name] = key
+	return nil
+}
+
+// AddIdentity stores an X25519 private key under name
+func (kr *Keyring) AddIdentity(name string, priv *PrivateKey) error {
+	if name == "" {
+		return errors.New("name is required")
+	}
+	kr.identities[name] = priv
+	return nil
+}
+
+// Remove deletes the key or identity stored under name
+func (kr *Keyring) Remove(name string) {
+	delete(kr.keys, name)
+	delete(kr.identities, name)
+}
+
+// Names returns the sorted names of all keys and identities
+func (kr *Keyring) Names() []string {
+	names := make([]string, 0, len(kr.keys)+len(kr.identities))
+	for name := range kr.keys {
+		names = append(names, name)
+	}
+	for name := range kr.identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyHintTag computes the keyed tag for a hint nonce
+func keyHintTag(key string, nonce []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("sentence-cipher key id"))
+	mac.Write(nonce)
+	return mac.Sum(nil)[:keyHintTagSize]
+}
+
+// withKeyHint compresses data and prepends the key-ID hint
+func withKeyHint(key string, data []byte) ([]byte, error) {
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+	hint := make([]byte, keyHintNonceSize, keyHintSize+len(compressed))
+	if _, err := rand.Read(hint); err != nil {
+		return nil, fmt.Errorf("key hint generation failed: %w", err)
+	}
+	hint = append(hint, keyHintTag(key, hint)...)
+	return append(hint, compressed...), nil
+}
+
+// Encode encodes data with the named key, embedding a key-ID hint
+func (kr *Keyring) Encode(name string, data []byte) (string, error) {
+	key, cipher, err := kr.cipher(name)
+	if err != nil {
+		return "", err
+	}
+	raw, err := withKeyHint(key, data)
+	if err != nil {
+		return "", err
+	}
+	return cipher.encodeRaw(raw), nil
+}
+
+// EncodeNatural encodes data as a natural email with the named key, embedding a key-ID hint
+func (kr *Keyring) EncodeNatural(name string, data []byte) (string, error) {
+	key, cipher, err := kr.cipher(name)
+	if err != nil {
+		return "", err
+	}
+	raw, err := withKeyHint(key, data)
+	if err != nil {
+		return "", err
+	}
+	return cipher.encodeNaturalRaw(raw), nil
+}
+
+// Decode finds the key that encoded a plain-mode message and returns the
+// data and the name of the key that succeeded
+func (kr *Keyring) Decode(encoded string) ([]byte, string, error) {
+	return kr.decode(encoded, (*Cipher).decodeRaw, (*Cipher).DecodeWith)
+}
+
+// DecodeNatural is Decode for natural-mode emails
+func (kr *Keyring) DecodeNatural(encoded string) ([]byte, string, error) {
+	return kr.decode(encoded, (*Cipher).decodeNaturalRaw, nil)
+}
+
+func (kr *Keyring) decode(encoded string, decodeRaw func(*Cipher, string) ([]byte, error),
+	decodeWith func(*Cipher, *PrivateKey, string) ([]byte, error)) ([]byte, string, error) {
+
+	// Only keys whose hint matches are tried: brotli accepts a fair share of
+	// random input, so decompressing with every key could return garbage
+	for _, name := range kr.sortedKeyNames() {
+		key, cipher, _ := kr.cipher(name)
+		raw, err := decodeRaw(cipher, encoded)
+		if err != nil || len(raw) < keyHintSize {
+			continue
+		}
+		if !hmac.Equal(raw[keyHintNonceSize:keyHintSize], keyHintTag(key, raw[:keyHintNonceSize])) {
+			continue
+		}
+		if data, err := decompress(raw[keyHintSize:]); err == nil {
+			return data, name, nil
+		}
+	}
+
+	// Public-key messages authenticate themselves
+	if decodeWith != nil {
+		for _, name := range kr.sortedIdentityNames() {
+			if data, err := decodeWith(NewDefaultCipher(), kr.identities[name], encoded); err == nil {
+				return data, name, nil
+			}
+		}
+	}
+
+	return nil, "", ErrNoMatchingKey
+}
+
+func (kr *Keyring) cipher(name string) (string, *Cipher, error) {
+	key, ok := kr.keys[name]
+	if !ok {
+		return "", nil, errors.New("unknown key: " + name)
+	}
+	cipher, err := NewCipher(key)
+	return key, cipher, err
+}
+
+func (kr *Keyring) sortedKeyNames() []string {
+	names := make([]string, 0, len(kr.keys))
+	for name := range kr.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (kr *Keyring) sortedIdentityNames() []string {
+	names := make([]string, 0, len(kr.identities))
+	for name := range kr.identities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keyringFileKey derives the file encryption key from a passphrase
+func keyringFileKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, chacha20poly1305.KeySize)
+}
+
+// MarshalEncrypted serializes the keyring encrypted under passphrase
+func (kr *Keyring) MarshalEncrypted(passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+
+	file := keyringFile{Keys: kr.keys, Identities: make(map[string]string)}
+	for name, priv := range kr.identities {
+		file.Identities[name] = priv.String()
+	}
+	plain, err := json.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, keyringSaltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	fileKey, err := keyringFileKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(append(append([]byte{}, keyringMagic...), salt...), nonce...)
+	return aead.Seal(out, nonce, plain, keyringMagic), nil
+}
+
+// UnmarshalKeyring decrypts a keyring produced by MarshalEncrypted
+func UnmarshalKeyring(data []byte, passphrase string) (*Keyring, error) {
+	headerSize := len(keyringMagic) + keyringSaltSize + chacha20poly1305.NonceSize
+	if len(data) < headerSize || !bytes.Equal(data[:len(keyringMagic)], keyringMagic) {
+		return nil, errors.New("not a keyring file")
+	}
+	salt := data[len(keyringMagic) : len(keyringMagic)+keyringSaltSize]
+	nonce := data[len(keyringMagic)+keyringSaltSize : headerSize]
+
+	fileKey, err := keyringFileKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, nonce, data[headerSize:], keyringMagic)
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted keyring")
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(plain, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyring contents: %w", err)
+	}
+
+	kr := NewKeyring()
+	for name, key := range file.Keys {
+		kr.keys[name] = key
+	}
+	for name, s := range file.Identities {
+		priv, err := ParsePrivateKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("identity %s: %w", name, err)
+		}
+		kr.identities[name] = priv
+	}
+	return kr, nil
+}
+
+// Save writes the keyring to path, encrypted under passphrase
+func (kr *Keyring) Save(path, passphrase string) error {
+	data, err := kr.MarshalEncrypted(passphrase)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadKeyring reads a keyring file written by Save
+func LoadKeyring(path, passphrase string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalKeyring(data, passphrase)
+}
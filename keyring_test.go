@@ -0,0 +1,106 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testKeyring(t *testing.T) *Keyring {
+	kr := NewKeyring()
+	for name, key := range map[string]string{"alpha": "alpha-key", "bravo": "bravo-key", "charlie": "charlie-key"} {
+		if err := kr.Add(name, key); err != nil {
+			t.Fatalf("Add error: %v", err)
+		}
+	}
+	return kr
+}
+
+func TestKeyringDecodeFindsKey(t *testing.T) {
+	kr := testKeyring(t)
+	input := []byte("Which key was it?")
+
+	for _, name := range []string{"alpha", "bravo", "charlie"} {
+		encoded, err := kr.Encode(name, input)
+		if err != nil {
+			t.Fatalf("Encode(%s) error: %v", name, err)
+		}
+		decoded, used, err := kr.Decode(encoded)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if used != name || !bytes.Equal(decoded, input) {
+			t.Errorf("Decode = %q via %s, want %q via %s", decoded, used, input, name)
+		}
+
+		natural, err := kr.EncodeNatural(name, input)
+		if err != nil {
+			t.Fatalf("EncodeNatural(%s) error: %v", name, err)
+		}
+		decoded, used, err = kr.DecodeNatural(natural)
+		if err != nil || used != name || !bytes.Equal(decoded, input) {
+			t.Errorf("DecodeNatural = %q via %s (%v), want %q via %s", decoded, used, err, input, name)
+		}
+	}
+}
+
+func TestKeyringDecodeUnknownKey(t *testing.T) {
+	kr := testKeyring(t)
+
+	other := NewKeyring()
+	other.Add("delta", "delta-key")
+	for i := 0; i < 20; i++ {
+		encoded, _ := other.Encode("delta", []byte("nobody here can read this"))
+		if data, used, err := kr.Decode(encoded); !errors.Is(err, ErrNoMatchingKey) {
+			t.Fatalf("expected ErrNoMatchingKey, got %q via %s (%v)", data, used, err)
+		}
+	}
+}
+
+func TestKeyringIdentity(t *testing.T) {
+	kr := testKeyring(t)
+	pub, priv, _ := GenerateKeyPair()
+	kr.AddIdentity("me", priv)
+
+	encoded, _ := EncodeFor(pub, []byte("sealed for me"))
+	decoded, used, err := kr.Decode(encoded)
+	if err != nil || used != "me" || string(decoded) != "sealed for me" {
+		t.Errorf("Decode = %q via %s (%v)", decoded, used, err)
+	}
+}
+
+func TestKeyringSaveLoad(t *testing.T) {
+	kr := testKeyring(t)
+	_, priv, _ := GenerateKeyPair()
+	kr.AddIdentity("me", priv)
+
+	dir, err := os.MkdirTemp("", "keyring")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "team.keyring")
+
+	if err := kr.Save(path, "file-passphrase"); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if _, err := LoadKeyring(path, "wrong-passphrase"); err == nil {
+		t.Error("expected error with the wrong passphrase")
+	}
+
+	loaded, err := LoadKeyring(path, "file-passphrase")
+	if err != nil {
+		t.Fatalf("LoadKeyring error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Names(), []string{"alpha", "bravo", "charlie", "me"}) {
+		t.Errorf("Names = %v", loaded.Names())
+	}
+
+	encoded, _ := kr.Encode("charlie", []byte("still works"))
+	if decoded, used, err := loaded.Decode(encoded); err != nil || used != "charlie" || string(decoded) != "still works" {
+		t.Errorf("Decode after load = %q via %s (%v)", decoded, used, err)
+	}
+}
@@ -0,0 +1,216 @@
+package sentencecipher
+
+import "errors"
+
+// sentencePlan is what one sentence carries before rotation: 1 to 3 slot
+// values and, for full sentences, a structure or, for short ones, a filler
+// ending (-1 when it carries nothing)
+type sentencePlan struct {
+	values    []int
+	structure int
+	ending    int
+}
+
+// planSentences lays out data in sentences. Full sentences carry 3 bytes in
+// their slots and bits more in their structure; what is left goes in short
+// sentences. When no full sentence would get a structure other
+// than the first, data is laid out byte by byte as before structures existed:
+// decoders tell the layouts apart by the structures alone.
+func planSentences(data []byte, bits, endingBits int) []sentencePlan {
+	plans := bitPlans(data, bits, endingBits)
+	for _, plan := range plans {
+		if plan.structure != 0 {
+			return plans
+		}
+	}
+	return bytePlans(data)
+}
+
+// bytePlans lays out data 3 bytes per full sentence, structures unused
+func bytePlans(data []byte) []sentencePlan {
+	var plans []sentencePlan
+	for i := 0; i < len(data); {
+		n := len(data) - i
+		if n > 3 {
+			n = 3
+		}
+		plan := sentencePlan{ending: -1}
+		for _, b := range data[i : i+n] {
+			plan.values = append(plan.values, int(b))
+		}
+		plans = append(plans, plan)
+		i += n
+	}
+	return plans
+}
+
+// bitPlans lays out data as a bit stream: 24+bits bits per full sentence,
+// then 8 or 16 bits per short sentence plus endingBits in its filler ending.
+// It uses the fewest sentences that leave fewer than 8 bits of zero padding,
+// so the decoder can tell the length.
+func bitPlans(data []byte, bits, endingBits int) []sentencePlan {
+	fullBits := 24 + bits
+	total := 8 * len(data)
+
+	// One full sentence more than fits, or fewer with short sentences after them
+	full, short := -1, []int(nil)
+	for n := total/fullBits + 1; n >= 0 && n >= total/fullBits-1; n-- {
+		sizes, ok := shortSizes(total-n*fullBits, endingBits)
+		if ok && (full < 0 || n+len(sizes) < full+len(short)) {
+			full, short = n, sizes
+		}
+	}
+	if full < 0 {
+		return bytePlans(data)
+	}
+
+	r := bitReader{data: data}
+	var plans []sentencePlan
+	for k := 0; k < full; k++ {
+		plans = append(plans, sentencePlan{
+			values:    []int{r.read(8), r.read(8), r.read(8)},
+			structure: r.read(bits),
+			ending:    -1,
+		})
+	}
+	for _, size := range short {
+		plan := sentencePlan{ending: -1}
+		for j := 0; j < size; j++ {
+			plan.values = append(plan.values, r.read(8))
+		}
+		if endingBits > 0 {
+			plan.ending = r.read(endingBits)
+		}
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// shortSizes returns the sizes (2-byte sentences first) of the fewest short
+// sentences that carry n bits with fewer than 8 bits of padding
+func shortSizes(n, endingBits int) ([]int, bool) {
+	if n <= 0 {
+		return nil, n > -8
+	}
+	one, two := 8+endingBits, 16+endingBits
+	var best []int
+	found := false
+	for twos := 0; twos <= n/two+1; twos++ {
+		for ones := 0; ones <= 2; ones++ {
+			pad := twos*two + ones*one - n
+			if pad < 0 || pad >= 8 || (found && twos+ones >= len(best)) {
+				continue
+			}
+			best = nil
+			for j := 0; j < twos+ones; j++ {
+				size := 1
+				if j < twos {
+					size = 2
+				}
+				best = append(best, size)
+			}
+			found = true
+		}
+	}
+	return best, found
+}
+
+// joinPlans reverses planSentences. A short sentence of the bit layout whose
+// filler ending is not in the key's pool (see fillers.go) cannot be read.
+func joinPlans(plans []sentencePlan, bits, endingBits int) ([]byte, error) {
+	bitLayout := false
+	for _, plan := range plans {
+		if len(plan.values) == 3 && plan.structure != 0 {
+			bitLayout = true
+		}
+	}
+
+	var w bitWriter
+	for _, plan := range plans {
+		for _, v := range plan.values {
+			w.write(v, 8)
+		}
+		switch {
+		case !bitLayout:
+		case len(plan.values) == 3:
+			w.write(plan.structure, bits)
+		case endingBits > 0 && plan.ending < 0:
+			return nil, errors.New("filler ending is not one of this key's")
+		case endingBits > 0:
+			w.write(plan.ending, endingBits)
+		}
+	}
+	return w.bytes(), nil
+}
+
+// slots rotates the plan's values to word-list indices; pos is the position
+// of its first value in the message. This rotation, shared by every mode,
+// keeps repeated bytes from repeating words.
+func (plan sentencePlan) slots(pos int) sentenceParse {
+	p := sentenceParse{s: -1, v: -1, io: -1, o: -1, ending: plan.ending, size: len(plan.values), structure: plan.structure}
+	idx := make([]int, len(plan.values))
+	for j, v := range plan.values {
+		idx[j] = (v + pos + j) % 256
+	}
+	p.s = idx[0]
+	if p.size > 1 {
+		p.v = idx[1]
+	}
+	if p.size > 2 {
+		p.o = idx[2]
+	}
+	return p
+}
+
+// plan reverses sentencePlan.slots
+func (p sentenceParse) plan(pos int) sentencePlan {
+	idx := []int{p.s, p.v, p.o}[:p.size]
+	plan := sentencePlan{structure: p.structure, ending: -1}
+	if p.size < 3 {
+		plan.ending = p.ending
+	}
+	for j, i := range idx {
+		plan.values = append(plan.values, ((i-pos-j)%256+256)%256)
+	}
+	return plan
+}
+
+// bitReader reads big-endian bit fields, returning zeros past the end
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		bit := 0
+		if byteIdx := r.pos / 8; byteIdx < len(r.data) {
+			bit = int(r.data[byteIdx]>>(7-r.pos%8)) & 1
+		}
+		v = v<<1 | bit
+		r.pos++
+	}
+	return v
+}
+
+// bitWriter collects big-endian bit fields
+type bitWriter struct {
+	data []byte
+	n    int
+}
+
+func (w *bitWriter) write(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		w.data[w.n/8] |= byte((v>>i)&1) << (7 - w.n%8)
+		w.n++
+	}
+}
+
+// bytes returns the whole bytes written; a partial last byte is padding
+func (w *bitWriter) bytes() []byte {
+	return w.data[:w.n/8]
+}
@@ -0,0 +1,256 @@
+package sentencecipher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown meeting-notes layout:
+//
+//	# <Subject>
+//
+//	**Attendees:** Name, Name, Name
+//
+//	## Notes
+//
+//	- Subject verb IndirectObject object.
+//	- Subject verb daily.
+//	- Subject works.
+//
+//	## Action items
+//
+//	- [ ] Subject to verb object with IndirectObject
+//	- [ ] Subject to verb daily
+//	- [ ] Subject to follow up
+//
+// Every bullet (notes and action items) is a data sentence. Action items are
+// written with their own patterns (markdownActionPatterns), use the base form of
+// the verb ("helps" -> "help") and always come last so byte order is kept.
+// "daily" and "works" stand for any filler ending (see fillers.go).
+
+// Section labels that may appear as plain lines once the Markdown is rendered
+var markdownLabels = []string{"notes", "action items", "attendees"}
+
+// encodeMarkdownRaw creates Markdown meeting notes without compression (internal use)
+func (c *Cipher) encodeMarkdownRaw(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	seed := c.envelopeSeed(data)
+	theme := c.coverTheme(seed)
+	themedCipher := c.themed(theme)
+	title, _, _ := themeEnvelope(theme, seed)
+
+	// Markdown keeps the byte-by-byte layout: action items have no structures.
+	// The IO parity votes for the theme, as in natural mode.
+	var sentences []sentenceParse
+	pos, k := 0, 0
+	for _, plan := range bytePlans(data) {
+		p := plan.slots(pos)
+		if p.size == 3 {
+			p.io = c.themeParity(theme, k, (p.s+p.v)%256)
+			k++
+		}
+		// Byte layout: the endings carry no data
+		p.ending = randomFiller()
+		sentences = append(sentences, p)
+		pos += p.size
+	}
+
+	// 1-3 trailing sentences become action items, keeping at least one note
+	actions := 1 + seed%3
+	if actions > len(sentences)-1 {
+		actions = len(sentences) - 1
+	}
+	notes := sentences[:len(sentences)-actions]
+
+	var sb strings.Builder
+	sb.WriteString("# " + title + "\n\n")
+
+	// Attendees are decoration only (3-5 names picked from the seed)
+	attendeeCount := 3 + seed%3
+	attendees := make([]string, 0, attendeeCount)
+	for j := 0; j < attendeeCount; j++ {
+		name := capitalize(themedCipher.names[(seed*7+j*31)%len(themedCipher.names)])
+		attendees = append(attendees, name)
+	}
+	sb.WriteString("**Attendees:** " + strings.Join(attendees, ", ") + "\n\n")
+
+	sb.WriteString("## Notes\n\n")
+	for _, p := range notes {
+		sb.WriteString("- " + capitalize(p.render(themedCipher)) + "\n")
+	}
+
+	if actions > 0 {
+		sb.WriteString("\n## Action items\n\n")
+		for _, p := range sentences[len(notes):] {
+			item := strings.Fields(strings.TrimSuffix(actionPatterns.render(themedCipher, p), "."))
+			for i, w := range item {
+				if w == themedCipher.names[p.s] || (p.io != -1 && w == themedCipher.names[p.io]) {
+					item[i] = capitalize(w)
+				}
+			}
+			sb.WriteString("- [ ] " + strings.Join(item, " ") + "\n")
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// decodeMarkdownRaw decodes Markdown meeting notes without decompression (internal use)
+func (c *Cipher) decodeMarkdownRaw(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return []byte{}, nil
+	}
+
+	lines := strings.Split(normalizeText(encoded), "\n")
+
+	// The first non-empty line is the title (a heading, or plain text once
+	// rendered), one hint among others for rankThemes
+	theme := ""
+	titleIdx := -1
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			theme = themeForSubject(strings.TrimSpace(strings.TrimLeft(line, "#")))
+			titleIdx = i
+			break
+		}
+	}
+
+	var items [][]string
+	var sentences []string
+	for _, line := range lines[titleIdx+1:] {
+		if words, ok := markdownItemWords(line); ok {
+			items = append(items, words)
+			sentences = append(sentences, strings.Join(words, " "))
+		}
+	}
+
+	// Try the themes from most to least likely, as natural mode does
+	ranked := c.rankThemes(sentences, theme)
+	var firstErr error
+	for _, candidate := range ranked.scores {
+		result, err := decodeMarkdownItems(c.themed(candidate.name), items)
+		if err == nil {
+			return result, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+// decodeMarkdownItems decodes the words of note and action items with a themed cipher
+func decodeMarkdownItems(themedCipher *Cipher, items [][]string) ([]byte, error) {
+	var plans []sentencePlan
+	pos := 0 // byte position for the rotation offset
+	for _, words := range items {
+		p, ok := themedCipher.parseSentence(words)
+		if !ok {
+			p, ok = actionPatterns.parse(themedCipher, words)
+		}
+		if !ok {
+			return nil, themedCipher.sentenceError(words)
+		}
+		plans = append(plans, p.plan(pos))
+		pos += p.size
+	}
+	return joinPlans(plans, themedCipher.patternSet().bits, themedCipher.patternSet().endingBits)
+}
+
+// markdownItemWords strips Markdown syntax from a line and returns its words.
+// Headings, labels and the attendee list are reported as not being items.
+func markdownItemWords(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.Contains(line, ":") {
+		return nil, false
+	}
+
+	// Bullet markers: "-", "*", "+", "•" or "1." / "1)"
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") {
+		line = line[2:]
+	} else if strings.HasPrefix(line, "•") {
+		line = strings.TrimPrefix(line, "•")
+	} else if n := strings.IndexAny(line, ".)"); n > 0 && isDigits(line[:n]) {
+		line = line[n+1:]
+	}
+	line = strings.TrimSpace(line)
+
+	// Task list checkboxes, raw or as rendered glyphs
+	for _, box := range []string{"[ ]", "[x]", "[X]", "☐", "☑", "☒", "✓", "✔"} {
+		if strings.HasPrefix(line, box) {
+			line = strings.TrimSpace(strings.TrimPrefix(line, box))
+			break
+		}
+	}
+
+	// Emphasis markers never occur inside data words
+	line = strings.NewReplacer("*", "", "_", "", "`", "").Replace(line)
+	line = strings.TrimSuffix(strings.TrimSpace(line), ".")
+
+	lower := strings.ToLower(line)
+	for _, label := range markdownLabels {
+		if lower == label {
+			return nil, false
+		}
+	}
+
+	words := strings.Fields(lower)
+	if len(words) == 0 {
+		return nil, false
+	}
+	return words, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeMarkdown compresses data then creates Markdown meeting notes
+func (c *Cipher) EncodeMarkdown(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return "", fmt.Errorf("compression failed: %w", err)
+	}
+	padded, err := c.padPayload(compressed)
+	if err != nil {
+		return "", err
+	}
+	return c.encodeMarkdownRaw(padded), nil
+}
+
+// DecodeMarkdown decodes Markdown meeting notes then decompresses
+func (c *Cipher) DecodeMarkdown(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return []byte{}, nil
+	}
+	compressed, err := c.decodeMarkdownRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return c.openPayload(compressed)
+}
+
+// EncodeMarkdown compresses then encodes as Markdown meeting notes (package-level)
+func EncodeMarkdown(data []byte) (string, error) {
+	return NewDefaultCipher().EncodeMarkdown(data)
+}
+
+// DecodeMarkdown decodes Markdown meeting notes then decompresses (package-level)
+func DecodeMarkdown(encoded string) ([]byte, error) {
+	return NewDefaultCipher().DecodeMarkdown(encoded)
+}
@@ -0,0 +1,148 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncodeMarkdownDecodeMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		input []byte
+	}{
+		{"empty", "", []byte{}},
+		{"hello", "", []byte("Hello")},
+		{"secret", "", []byte("Secret message")},
+		{"thai", "", []byte("สวัสดี")},
+		{"with key", "md-key", []byte("Meet at the usual place at 9pm")},
+		{"binary with key", "binary-key", []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cipher := NewDefaultCipher()
+			if tt.key != "" {
+				var err error
+				cipher, err = NewCipher(tt.key)
+				if err != nil {
+					t.Fatalf("NewCipher error: %v", err)
+				}
+			}
+
+			encoded, err := cipher.EncodeMarkdown(tt.input)
+			if err != nil {
+				t.Fatalf("EncodeMarkdown error: %v", err)
+			}
+			decoded, err := cipher.DecodeMarkdown(encoded)
+			if err != nil {
+				t.Fatalf("DecodeMarkdown error: %v\n%s", err, encoded)
+			}
+			t.Logf("Encoded:\n%s", encoded)
+			if !bytes.Equal(decoded, tt.input) {
+				t.Errorf("EncodeMarkdown/DecodeMarkdown mismatch\noriginal: %v\ndecoded:  %v", tt.input, decoded)
+			}
+		})
+	}
+}
+
+func TestMarkdownLayout(t *testing.T) {
+	encoded, err := EncodeMarkdown([]byte("The quick brown fox jumps over the lazy dog"))
+	if err != nil {
+		t.Fatalf("EncodeMarkdown error: %v", err)
+	}
+
+	for _, want := range []string{"# ", "**Attendees:**", "## Notes", "- "} {
+		if !strings.Contains(encoded, want) {
+			t.Errorf("Markdown output should contain %q:\n%s", want, encoded)
+		}
+	}
+}
+
+func TestDecodeMarkdownRenderedVariants(t *testing.T) {
+	input := []byte("Action items survive rendering")
+	cipher, _ := NewCipher("render-key")
+	encoded, err := cipher.EncodeMarkdown(input)
+	if err != nil {
+		t.Fatalf("EncodeMarkdown error: %v", err)
+	}
+
+	variants := map[string]string{
+		"star bullets":  strings.ReplaceAll(encoded, "\n- ", "\n* "),
+		"plus bullets":  strings.ReplaceAll(encoded, "\n- ", "\n+ "),
+		"checked boxes": strings.ReplaceAll(encoded, "[ ]", "[x]"),
+		"crlf":          strings.ReplaceAll(encoded, "\n", "\r\n"),
+		"rendered text": strings.NewReplacer("# ", "", "**", "", "- [ ] ", "☐ ", "- ", "• ").Replace(encoded),
+	}
+
+	for name, text := range variants {
+		t.Run(name, func(t *testing.T) {
+			decoded, err := cipher.DecodeMarkdown(text)
+			if err != nil {
+				t.Fatalf("DecodeMarkdown error: %v\n%s", err, text)
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Errorf("DecodeMarkdown mismatch\noriginal: %q\ndecoded:  %q", input, decoded)
+			}
+		})
+	}
+}
+
+func TestMarkdownSurvivesRewrittenTitle(t *testing.T) {
+	if err := RegisterTheme(testTheme("minutes")); err != nil {
+		t.Fatalf("RegisterTheme error: %v", err)
+	}
+	t.Cleanup(func() { unregisterTheme("minutes") })
+	themed, _ := NewCipher("markdown-key")
+	if err := themed.SetTheme("minutes"); err != nil {
+		t.Fatalf("SetTheme error: %v", err)
+	}
+	plain, _ := NewCipher("markdown-key")
+	receiver, _ := NewCipher("markdown-key")
+	input := []byte("The title is not part of the message")
+
+	for i := 0; i < 8; i++ {
+		for _, sender := range []*Cipher{plain, themed} {
+			encoded, err := sender.EncodeMarkdown(input)
+			if err != nil {
+				t.Fatalf("EncodeMarkdown error: %v", err)
+			}
+			lines := strings.SplitN(encoded, "\n", 2)
+			for _, title := range []string{"# Weekly sync", "Server Maintenance", ""} {
+				decoded, err := receiver.DecodeMarkdown(title + "\n" + lines[1])
+				if err != nil || !bytes.Equal(decoded, input) {
+					t.Fatalf("title %q: DecodeMarkdown = %q, %v\n%s", title, decoded, err, encoded)
+				}
+			}
+		}
+	}
+}
+
+func TestVerbBaseFormSpelling(t *testing.T) {
+	for verb, want := range map[string]string{
+		"reviews": "review", "analyzes": "analyze", "optimizes": "optimize",
+		"organizes": "organize", "finalizes": "finalize", "buzzes": "buzz",
+		"clarifies": "clarify", "passes": "pass", "pushes": "push",
+		"teaches": "teach", "fixes": "fix", "closes": "close",
+		"advises": "advise", "parses": "parse", "caches": "cache",
+		"focuses": "focus", "echoes": "echo",
+	} {
+		if got := verbBaseForm(verb); got != want {
+			t.Errorf("verbBaseForm(%q) = %q, want %q", verb, got, want)
+		}
+	}
+}
+
+func TestVerbBaseFormUnique(t *testing.T) {
+	for name, list := range map[string][]string{"defaultVerbs": defaultVerbs, "techVerbs": techVerbs} {
+		seen := make(map[string]string)
+		for _, v := range list {
+			base := verbBaseForm(v)
+			if prev, ok := seen[base]; ok {
+				t.Errorf("%s: %q and %q share base form %q", name, prev, v, base)
+			}
+			seen[base] = v
+		}
+	}
+}
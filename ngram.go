@@ -0,0 +1,169 @@
+package sentencecipher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A small n-gram language model for fluent mode (see fluent.go). The model
+// predicts the next word of a sentence from the two words before it: the
+// bigram counts of the previous word, plus trigramWeight times the trigram
+// counts of the two previous words. Every word the model can produce after a
+// context has a non-zero count, and counts are integers, so the encoder and
+// the decoder derive exactly the same distribution for arithmetic coding.
+//
+// Models are versioned. A version is trained from a frozen corpus and never
+// changes, so text written with it keeps decoding after newer models ship.
+
+const (
+	// trigramWeight favors continuations seen after both previous words
+	trigramWeight = 8
+	// shortSentence is the length before which a sentence only ends where
+	// the corpus ends one after the same two words
+	shortSentence = 4
+	// longSentence is the length after which the end of a sentence gets
+	// more likely with every word, so sentences do not run on
+	longSentence = 12
+)
+
+// sentenceStart is the context word before the first word of a sentence
+const sentenceStart = -1
+
+// sentenceEndWord is the token that ends a sentence
+const sentenceEndWord = "."
+
+// ngramModel is one version of the language model
+type ngramModel struct {
+	version int
+	words   []string       // sorted vocabulary, including sentenceEndWord
+	index   map[string]int // word -> position in words
+	bigrams map[int]map[int]int
+	trigram map[[2]int]map[int]int
+}
+
+// ngramContext is the state of the model inside a sentence
+type ngramContext struct {
+	prev2, prev int
+	length      int
+}
+
+// languageModels holds every released model by version
+var languageModels = map[int]*ngramModel{}
+
+// latestModelVersion is the model new text is written with by default
+var latestModelVersion int
+
+func init() {
+	for version, corpus := range map[int][]string{
+		1: officeCorpusV1,
+	} {
+		languageModels[version] = trainNgramModel(version, corpus)
+		if version > latestModelVersion {
+			latestModelVersion = version
+		}
+	}
+}
+
+// trainNgramModel counts the bigrams and trigrams of corpus, one sentence per entry
+func trainNgramModel(version int, corpus []string) *ngramModel {
+	m := &ngramModel{
+		version: version,
+		index:   map[string]int{},
+		bigrams: map[int]map[int]int{},
+		trigram: map[[2]int]map[int]int{},
+	}
+
+	seen := map[string]bool{sentenceEndWord: true}
+	for _, line := range corpus {
+		for _, w := range strings.Fields(line) {
+			seen[w] = true
+		}
+	}
+	for w := range seen {
+		m.words = append(m.words, w)
+	}
+	sort.Strings(m.words)
+	for i, w := range m.words {
+		m.index[w] = i
+	}
+
+	for _, line := range corpus {
+		ctx := m.start()
+		for _, w := range append(strings.Fields(line), sentenceEndWord) {
+			next := m.index[w]
+			addCount(m.bigrams, ctx.prev, next)
+			if ctx.prev != sentenceStart {
+				key := [2]int{ctx.prev2, ctx.prev}
+				if m.trigram[key] == nil {
+					m.trigram[key] = map[int]int{}
+				}
+				m.trigram[key][next]++
+			}
+			ctx = m.advance(ctx, next)
+		}
+	}
+	return m
+}
+
+// addCount counts one occurrence of next after context
+func addCount(counts map[int]map[int]int, context, next int) {
+	if counts[context] == nil {
+		counts[context] = map[int]int{}
+	}
+	counts[context][next]++
+}
+
+// start returns the context at the beginning of a sentence
+func (m *ngramModel) start() ngramContext {
+	return ngramContext{prev2: sentenceStart, prev: sentenceStart}
+}
+
+// advance moves the context past word, starting over after the end of a sentence
+func (m *ngramModel) advance(ctx ngramContext, word int) ngramContext {
+	if m.words[word] == sentenceEndWord {
+		return m.start()
+	}
+	return ngramContext{prev2: ctx.prev, prev: word, length: ctx.length + 1}
+}
+
+// distribution returns the possible next words in vocabulary order with their frequencies
+func (m *ngramModel) distribution(ctx ngramContext) (words, freqs []int) {
+	bigrams := m.bigrams[ctx.prev]
+	trigrams := m.trigram[[2]int{ctx.prev2, ctx.prev}]
+	for w := range bigrams {
+		if m.words[w] == sentenceEndWord && ctx.length < shortSentence && trigrams[w] == 0 {
+			continue
+		}
+		words = append(words, w)
+	}
+	sort.Ints(words)
+
+	freqs = make([]int, len(words))
+	for i, w := range words {
+		freqs[i] = bigrams[w] + trigramWeight*trigrams[w]
+		if m.words[w] == sentenceEndWord && ctx.length > longSentence {
+			freqs[i] *= ctx.length - longSentence + 1
+		}
+	}
+	return words, freqs
+}
+
+// lookupModel returns the released model with the given version
+func lookupModel(version int) (*ngramModel, error) {
+	m, ok := languageModels[version]
+	if !ok {
+		return nil, fmt.Errorf("unknown language model version %d", version)
+	}
+	return m, nil
+}
+
+// LanguageModelVersions returns the versions of the built-in language models, oldest first
+func LanguageModelVersions() []int {
+	versions := make([]int, 0, len(languageModels))
+	for v := range languageModels {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
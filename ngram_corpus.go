@@ -0,0 +1,249 @@
+package sentencecipher
+
+// officeCorpusV1 trains language model version 1 (see ngram.go). Released
+// corpora are frozen: text written with them must keep decoding, so new
+// sentences go into a new corpus and a new model version.
+var officeCorpusV1 = []string{
+	"thanks for the update on the budget",
+	"thanks for the quick turnaround on the report",
+	"thanks for sending the slides before the meeting",
+	"thanks everyone for joining the call today",
+	"thanks again for your help with the launch",
+	"thanks for flagging the issue with the invoice",
+	"thanks for pulling the numbers together so quickly",
+	"please find the updated report attached",
+	"please find the agenda for tomorrow attached",
+	"please find the notes from the workshop below",
+	"please review the draft before the end of the week",
+	"please review the proposal and send your comments",
+	"please send me your comments by friday",
+	"please send the final version to the client",
+	"please share the slides with the whole team",
+	"please share your availability for next week",
+	"please let me know if you have any questions",
+	"please let me know if anything is missing",
+	"please let us know if the new time works for you",
+	"please update the tracker when the task is done",
+	"please update the forecast with the latest numbers",
+	"please check the calendar invite for the new room",
+	"please add your name to the list by monday",
+	"please keep the client in the loop",
+	"please join the call a few minutes early",
+	"let me know if you need anything else",
+	"let me know if you have any questions",
+	"let me know what you think of the draft",
+	"let me know when the report is ready",
+	"let me know if the new date works for you",
+	"let us know if you can make the meeting",
+	"let us schedule a quick call to discuss the plan",
+	"let us move the review to next week",
+	"let us keep the discussion focused on the budget",
+	"we will review the proposal on monday",
+	"we will review the numbers at the next meeting",
+	"we will send the final report to the client on friday",
+	"we will share the slides after the call",
+	"we will share an update by the end of the week",
+	"we will need your sign off before the launch",
+	"we will need more time to finish the analysis",
+	"we will follow up with the vendor next week",
+	"we will follow up on the open items tomorrow",
+	"we will discuss the timeline at the next meeting",
+	"we will discuss the budget with the finance team",
+	"we will schedule a review once the draft is ready",
+	"we will keep you posted on the progress",
+	"we need to finalize the budget before the end of the quarter",
+	"we need to update the timeline for the project",
+	"we need to confirm the dates with the client",
+	"we need to align on the scope before we start",
+	"we need a decision on the vendor by friday",
+	"we need more data before we can decide",
+	"we need to hire two more people for the team",
+	"we need to move the launch to next month",
+	"we agreed to move the deadline to next friday",
+	"we agreed to keep the current scope for now",
+	"we agreed to review the plan again next week",
+	"we discussed the budget for the next quarter",
+	"we discussed the timeline and the open risks",
+	"we discussed the feedback from the client",
+	"we are still waiting for the numbers from finance",
+	"we are still waiting for feedback from the client",
+	"we are on track to finish the project this month",
+	"we are on track for the launch next week",
+	"we are behind on the testing but catching up",
+	"we are looking into the issue with the report",
+	"we are planning a workshop with the new team",
+	"we have a meeting with the client on thursday",
+	"we have enough budget for the new tools",
+	"we have a few open questions about the scope",
+	"we have finished the first draft of the proposal",
+	"we have moved the meeting to the large room",
+	"we should schedule a call with the vendor",
+	"we should update the client before the launch",
+	"we should review the contract with legal",
+	"we should keep the meeting short this week",
+	"we can discuss the details at the next meeting",
+	"we can move the review to thursday if needed",
+	"we can share the report once it is approved",
+	"we can add this to the agenda for monday",
+	"the meeting has been moved to thursday",
+	"the meeting will start at ten in the large room",
+	"the meeting ran over so we will continue tomorrow",
+	"the meeting notes are in the shared folder",
+	"the report is almost ready for review",
+	"the report will be ready by the end of the day",
+	"the report shows a small increase in sales",
+	"the report needs one more round of review",
+	"the budget has been approved by finance",
+	"the budget for the next quarter is still open",
+	"the budget review is scheduled for next week",
+	"the client approved the proposal this morning",
+	"the client asked for a few changes to the contract",
+	"the client is happy with the progress so far",
+	"the client wants to see the numbers before the meeting",
+	"the client will join the call on friday",
+	"the project is on track for the end of the month",
+	"the project is behind schedule by a week",
+	"the project plan has been updated with the new dates",
+	"the project team will meet every monday",
+	"the team is working on the final draft",
+	"the team is waiting for the new tools",
+	"the team did a great job on the launch",
+	"the team will present the results next week",
+	"the team has finished the first round of testing",
+	"the new process will start next month",
+	"the new tools are now available for the team",
+	"the new office opens next month",
+	"the new hire starts on monday",
+	"the deadline for the proposal is friday",
+	"the deadline has been moved to next week",
+	"the timeline looks tight but it is doable",
+	"the timeline depends on the feedback from the client",
+	"the slides are in the shared folder",
+	"the slides need a few more changes before the meeting",
+	"the draft is ready for your review",
+	"the draft has been sent to the client",
+	"the invoice has been sent to finance",
+	"the invoice is still waiting for approval",
+	"the vendor confirmed the new dates",
+	"the vendor will send a new quote next week",
+	"the contract is with legal for review",
+	"the contract needs to be signed by friday",
+	"the numbers look good for this quarter",
+	"the numbers from finance are still missing",
+	"the workshop went well and the feedback was positive",
+	"the feedback from the team was very positive",
+	"the results will be shared at the next meeting",
+	"the launch went well overall",
+	"the launch has been moved to next month",
+	"the room is booked for the whole afternoon",
+	"the call has been moved to the afternoon",
+	"the call will be short this week",
+	"the agenda for the meeting is below",
+	"the tracker has been updated with the latest status",
+	"the forecast has been updated with the new numbers",
+	"the analysis shows a clear trend in the data",
+	"the training session is scheduled for next week",
+	"the office will be closed on friday",
+	"the quarterly review is coming up next month",
+	"i will send the updated slides this afternoon",
+	"i will share the notes after the meeting",
+	"i will follow up with the client tomorrow",
+	"i will follow up with finance on the invoice",
+	"i will update the tracker after the call",
+	"i will be out of the office on friday",
+	"i will be in meetings most of the day",
+	"i will check with the team and get back to you",
+	"i will set up a call for next week",
+	"i will add this to the agenda",
+	"i have updated the report with your comments",
+	"i have shared the draft with the team",
+	"i have booked the large room for the workshop",
+	"i have attached the latest version of the proposal",
+	"i have sent the invoice to finance",
+	"i have moved the meeting to thursday",
+	"i think we should move the launch to next month",
+	"i think the proposal looks good overall",
+	"i think we need more time for testing",
+	"i think the client will be happy with the results",
+	"i am working on the final version of the report",
+	"i am waiting for the numbers from finance",
+	"i am out of the office until monday",
+	"i am happy to help with the review",
+	"i agree with the plan for next week",
+	"i agree that we should keep the scope small",
+	"could you send me the latest numbers",
+	"could you review the draft before friday",
+	"could you share the slides from the meeting",
+	"could you update the tracker with the new dates",
+	"could you check the budget for the new tools",
+	"could we move the call to the afternoon",
+	"could we schedule a review for next week",
+	"can you join the call on thursday",
+	"can you send the report to the client",
+	"can you take a look at the contract",
+	"can we discuss this at the next meeting",
+	"can we push the deadline to next week",
+	"just a quick reminder about the meeting tomorrow",
+	"just a quick update on the project",
+	"just a reminder that the report is due on friday",
+	"just checking in on the status of the proposal",
+	"just following up on the invoice from last month",
+	"just wanted to share the notes from the call",
+	"a few updates from the team this week",
+	"a quick summary of the meeting is below",
+	"a new version of the report is in the shared folder",
+	"a decision on the budget is needed by friday",
+	"good progress on the project this week",
+	"good news from the client this morning",
+	"great job on the launch everyone",
+	"great work on the report",
+	"great to see the numbers going up",
+	"sales are up compared to last quarter",
+	"costs are slightly above the budget this month",
+	"testing is almost done for the new release",
+	"hiring for the new team is going well",
+	"finance has approved the new budget",
+	"legal is reviewing the contract this week",
+	"marketing will share the campaign results on monday",
+	"support has seen fewer tickets this month",
+	"everyone did a great job this quarter",
+	"everyone is invited to the team lunch on friday",
+	"next steps are listed at the end of the notes",
+	"next week we will focus on testing",
+	"next month we will start the new project",
+	"this week we finished the first draft",
+	"this week the team focused on the launch",
+	"this is a reminder about the deadline on friday",
+	"this should be ready by the end of the week",
+	"this will help us finish the project on time",
+	"looking forward to the meeting on thursday",
+	"looking forward to your feedback on the draft",
+	"happy to discuss this further on the call",
+	"happy to help if you need anything",
+	"sorry for the late reply",
+	"sorry for the short notice",
+	"apologies for the delay on the report",
+	"as discussed the deadline is now next friday",
+	"as discussed we will move the meeting to monday",
+	"as mentioned the budget is still open",
+	"as a next step we will review the numbers",
+	"as always let me know if you have any questions",
+	"in the meantime please review the draft",
+	"in the meantime we will keep working on the report",
+	"on monday we will review the plan with the team",
+	"on friday the office will close early",
+	"after the meeting we will share the notes",
+	"before the launch we need to finish the testing",
+	"once the budget is approved we can start",
+	"once the contract is signed we will send the invoice",
+	"if you have any questions please let me know",
+	"if the client agrees we will start next week",
+	"if needed we can move the call to friday",
+	"when you have a moment please review the proposal",
+	"when the report is ready please send it to the client",
+	"have a great weekend everyone",
+	"have a good week",
+	"talk to you on monday",
+	"see you at the meeting tomorrow",
+	"see everyone at the workshop next week",
+}
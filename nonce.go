@@ -0,0 +1,104 @@
+package sentencecipher
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Randomized encoding starts the text with nonceSentenceCount nonce sentences,
+// the j-th one being
+//
+//	names[n2j] verbs[n2j+1] names[t0] objects[t1].
+//
+// in a random full sentence structure, where n0..n7 is a random 8-byte nonce and
+// t0 t1 = HMAC-SHA256(key, j || n2j || n2j+1)[0:2] marks the sentence as the j-th
+// piece of the nonce. Every data byte is then shifted by a keystream derived from
+// the key and the nonce before the usual rotation, so the same payload produces
+// different words each time. A first data sentence that happens to look like
+// the first nonce sentence gets a different (ignored) indirect object.
+const (
+	nonceSize          = 8
+	nonceSentenceCount = nonceSize / 2
+)
+
+// SetRandomized makes Encode and EncodeNatural mix a random nonce into every
+// encoding. Decoding recognizes randomized text automatically.
+func (c *Cipher) SetRandomized(on bool) {
+	c.randomized = on
+}
+
+// nonceTag returns the indirect-object and object indices that mark the j-th
+// nonce sentence with subject n0 and verb n1
+func (c *Cipher) nonceTag(j, n0, n1 int) (io, o int) {
+	macKey := sha256.Sum256([]byte("sentence-cipher/nonce/tag:" + c.key))
+	mac := hmac.New(sha256.New, macKey[:])
+	mac.Write([]byte{byte(j), byte(n0), byte(n1)})
+	sum := mac.Sum(nil)
+	return int(sum[0]), int(sum[1])
+}
+
+// isNonce reports whether the word indices of a full sentence form the j-th nonce sentence
+func (c *Cipher) isNonce(j, s, v, io, o int) bool {
+	tagIO, tagO := c.nonceTag(j, s, v)
+	return io == tagIO && o == tagO
+}
+
+// newNonce picks a random nonce none of whose sentences can be mistaken for
+// chaff and returns it with data shifted by its keystream
+func (c *Cipher) newNonce(data []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, nonceSize)
+	for chaff := true; chaff; {
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, nil, fmt.Errorf("nonce generation failed: %w", err)
+		}
+		chaff = false
+		for j := 0; j < nonceSentenceCount; j++ {
+			n0, n1 := int(nonce[2*j]), int(nonce[2*j+1])
+			io, o := c.nonceTag(j, n0, n1)
+			chaff = chaff || c.isChaff(n0, n1, io, o)
+		}
+	}
+	mixed := append([]byte{}, data...)
+	c.shiftNonceKeystream(nonce, mixed, 1)
+	return nonce, mixed, nil
+}
+
+// nonceSentences renders the sentences of a nonce with words' vocabulary
+func (c *Cipher) nonceSentences(nonce []byte, words *Cipher) []string {
+	sentences := make([]string, nonceSentenceCount)
+	for j := range sentences {
+		n0, n1 := int(nonce[2*j]), int(nonce[2*j+1])
+		io, o := c.nonceTag(j, n0, n1)
+		sentences[j] = fullSentence(words, n0, n1, io, o)
+	}
+	return sentences
+}
+
+// shiftNonceKeystream adds (sign 1) or subtracts (sign -1) the keystream for
+// nonce to data in place (SHA-256 in counter mode keyed by key and nonce)
+func (c *Cipher) shiftNonceKeystream(nonce, data []byte, sign int) {
+	seed := sha256.Sum256(append([]byte("sentence-cipher/nonce/stream:"+c.key+":"), nonce...))
+	var block [sha256.Size]byte
+	var counter [8]byte
+	for i := range data {
+		if i%sha256.Size == 0 {
+			binary.BigEndian.PutUint64(counter[:], uint64(i/sha256.Size))
+			block = sha256.Sum256(append(seed[:], counter[:]...))
+		}
+		data[i] = byte(int(data[i]) + sign*int(block[i%sha256.Size]))
+	}
+}
+
+// dataIndirect returns an indirect-object index for a data sentence that
+// cannot be mistaken for chaff or, for the first sentence, for the first nonce
+// sentence.
+// It keeps the parity of io, which carries the theme in natural mode.
+func (c *Cipher) dataIndirect(first bool, s, v, io, o int) int {
+	for c.isChaff(s, v, io, o) || (first && c.isNonce(0, s, v, io, o)) {
+		io = (io + 2) % 256
+	}
+	return io
+}
@@ -0,0 +1,133 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dataWords strips the random indirect objects so plain-mode encodings can
+// be compared
+func dataWords(c *Cipher, text string) string {
+	var words []string
+	for _, sentence := range splitSentences(text) {
+		p, ok := c.parseSentence(strings.Fields(trimSentenceEnd(sentence)))
+		if !ok {
+			return text
+		}
+		p.io = -1
+		words = append(words, fmt.Sprint(p))
+	}
+	return strings.Join(words, " ")
+}
+
+func TestRandomizedEncodingVaries(t *testing.T) {
+	cipher, _ := NewCipher("nonce-key")
+	input := []byte("Same message every time")
+
+	plainA, _ := cipher.Encode(input)
+	plainB, _ := cipher.Encode(input)
+	if dataWords(cipher, plainA) != dataWords(cipher, plainB) {
+		t.Fatal("deterministic encodings should share their data words")
+	}
+
+	cipher.SetRandomized(true)
+	seen := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		text, err := cipher.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+		words := dataWords(cipher, text)
+		if seen[words] {
+			t.Errorf("randomized encoding repeated: %q", text)
+		}
+		seen[words] = true
+
+		decoded, err := cipher.Decode(text)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+	}
+}
+
+func TestRandomizedNatural(t *testing.T) {
+	sender, _ := NewCipher("nonce-key")
+	sender.SetRandomized(true)
+	receiver, _ := NewCipher("nonce-key")
+	input := []byte("Randomized natural email")
+
+	a, err := sender.EncodeNatural(input)
+	if err != nil {
+		t.Fatalf("EncodeNatural error: %v", err)
+	}
+	b, _ := sender.EncodeNatural(input)
+	if a == b {
+		t.Error("randomized natural encodings should differ")
+	}
+	for _, text := range []string{a, b} {
+		decoded, err := receiver.DecodeNatural(text)
+		if err != nil {
+			t.Fatalf("DecodeNatural error: %v", err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+	}
+}
+
+func TestRandomizedWithChaff(t *testing.T) {
+	cipher, _ := NewCipher("nonce-key")
+	cipher.SetRandomized(true)
+	cipher.SetChaffRatio(1)
+	input := []byte("Nonce and chaff together")
+
+	for i := 0; i < 10; i++ {
+		text, _ := cipher.EncodeNatural(input)
+		decoded, err := cipher.DecodeNatural(text)
+		if err != nil || !bytes.Equal(decoded, input) {
+			t.Fatalf("DecodeNatural = %q, %v", decoded, err)
+		}
+	}
+}
+
+func TestRandomizedDefaultCipher(t *testing.T) {
+	cipher := NewDefaultCipher()
+	cipher.SetRandomized(true)
+	input := []byte("No key needed")
+	text, _ := cipher.Encode(input)
+	decoded, err := Decode(text)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("Decode = %q, %v", decoded, err)
+	}
+}
+
+func TestNonceSpansSentences(t *testing.T) {
+	cipher, _ := NewCipher("nonce-key")
+	cipher.SetRandomized(true)
+	input := []byte("Eight bytes of nonce")
+	text, _ := cipher.Encode(input)
+
+	sentences := splitSentences(text)
+	var nonce []byte
+	for j, sentence := range sentences[:nonceSentenceCount] {
+		s, v, io, o, ok := cipher.fullSentenceIndices(strings.Fields(strings.ToLower(trimSentenceEnd(sentence))))
+		if !ok || !cipher.isNonce(j, s, v, io, o) {
+			t.Fatalf("sentence %d is not a nonce sentence: %s", j, sentence)
+		}
+		nonce = append(nonce, byte(s), byte(v))
+	}
+	if len(nonce) != nonceSize {
+		t.Errorf("nonce has %d bytes, want %d", len(nonce), nonceSize)
+	}
+
+	// Dropping a nonce sentence must not decode to garbage
+	damaged := strings.Join(append(sentences[:1:1], sentences[2:]...), " ")
+	if _, err := cipher.Decode(damaged); err == nil {
+		t.Error("expected error for a missing nonce sentence")
+	}
+}
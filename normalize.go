@@ -0,0 +1,91 @@
+package sentencecipher
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Email clients and forwarding mangle text in predictable ways. normalizeText
+// undoes the damage before sentences are split, so every decoder accepts:
+//   - CRLF and CR line endings
+//   - "> " quote prefixes, including nested ones ("> > ")
+//   - non-breaking, thin and ideographic spaces, and zero-width characters
+//   - smart quotes, dashes, ellipses and full-width periods
+//
+// Hard-wrapped lines need no help in plain and Markdown modes, where line
+// breaks are just whitespace; natural mode rejoins them with reflowEmailLines.
+
+var quotePrefix = regexp.MustCompile(`(?m)^[ \t]*(?:>[ \t]?)+`)
+
+var punctuationReplacer = strings.NewReplacer(
+	"\r\n", "\n", "\r", "\n",
+	// Spaces
+	"\u00a0", " ", "\u2000", " ", "\u2001", " ", "\u2002", " ", "\u2003", " ",
+	"\u2004", " ", "\u2005", " ", "\u2006", " ", "\u2007", " ", "\u2008", " ",
+	"\u2009", " ", "\u200a", " ", "\u202f", " ", "\u205f", " ", "\u3000", " ",
+	// Invisible characters
+	"\u200b", "", "\u200c", "", "\u200d", "", "\u2060", "", "\ufeff", "", "\u00ad", "",
+	// Quotes and dashes
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201c", `"`, "\u201d", `"`, "\u201e", `"`,
+	"\u2010", "-", "\u2011", "-", "\u2012", "-", "\u2013", "-", "\u2014", "-", "\u2212", "-",
+	// Sentence punctuation
+	"\u2026", "...", "\uff0e", ".", "\u3002", ".", "\uff0c", ",", "\u3001", ",",
+)
+
+// normalizeText undoes email-client damage (see above)
+func normalizeText(text string) string {
+	text = punctuationReplacer.Replace(text)
+	return quotePrefix.ReplaceAllString(text, "")
+}
+
+// reflowEmailLines rejoins natural-mode body lines that were hard-wrapped
+// mid-sentence. A line is continued on the next one when it does not end a
+// sentence and is not a subject, opener or closer.
+func reflowEmailLines(lines []string) []string {
+	var out []string
+	continues := false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continues = false
+			out = append(out, line)
+			continue
+		}
+		if continues && !isEmailCloser(line) {
+			out[len(out)-1] += " " + line
+		} else {
+			out = append(out, line)
+		}
+		last := out[len(out)-1]
+		continues = !strings.HasSuffix(last, ".") && !strings.HasSuffix(last, "?") &&
+			!strings.HasPrefix(strings.ToLower(last), "subject:") &&
+			!isEmailOpener(last) && !isEmailCloser(last)
+	}
+	return out
+}
+
+func isEmailOpener(line string) bool {
+	return isEnvelopeLine(line, emailOpeners, func(t Theme) []string { return t.Openers })
+}
+
+func isEmailCloser(line string) bool {
+	return isEnvelopeLine(line, emailClosers, func(t Theme) []string { return t.Closers })
+}
+
+// isEnvelopeLine reports whether line is one of the built-in lines or of a registered theme's
+func isEnvelopeLine(line string, builtin []string, own func(Theme) []string) bool {
+	lists := [][]string{builtin}
+	for _, name := range ThemeNames() {
+		if rt, ok := lookupTheme(name); ok {
+			lists = append(lists, own(rt.Theme))
+		}
+	}
+	for _, list := range lists {
+		for _, l := range list {
+			if strings.EqualFold(strings.TrimSpace(l), line) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// wrapText hard-wraps every line at width columns, like a mail client does
+func wrapText(text string, width int) string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(line) {
+			if current != "" && len(current)+1+len(word) > width {
+				out = append(out, current)
+				current = word
+			} else if current == "" {
+				current = word
+			} else {
+				current += " " + word
+			}
+		}
+		out = append(out, current)
+	}
+	return strings.Join(out, "\n")
+}
+
+// quoteText prefixes every line with prefix, like a reply does
+func quoteText(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mangledCorpus applies realistic email-client damage to an encoding
+var mangledCorpus = []struct {
+	name   string
+	mangle func(string) string
+}{
+	{"quoted", func(s string) string { return quoteText(s, "> ") }},
+	{"nested quotes", func(s string) string { return quoteText(quoteText(s, "> "), "> ") }},
+	{"tight quotes", func(s string) string { return quoteText(s, ">>") }},
+	{"wrapped at 72", func(s string) string { return wrapText(s, 72) }},
+	{"wrapped at 40", func(s string) string { return wrapText(s, 40) }},
+	{"CRLF", func(s string) string { return strings.ReplaceAll(s, "\n", "\r\n") }},
+	{"non-breaking spaces", func(s string) string { return strings.ReplaceAll(s, " ", "\u00a0") }},
+	{"zero-width spaces", func(s string) string { return strings.ReplaceAll(s, ". ", ".\u200b ") }},
+	{"full-width periods", func(s string) string { return strings.ReplaceAll(s, ".", "\uff0e") }},
+	{"wrapped, quoted, CRLF", func(s string) string {
+		return strings.ReplaceAll(quoteText(wrapText(s, 72), "> "), "\n", "\r\n")
+	}},
+	{"wrapped and nested quotes", func(s string) string {
+		return quoteText(quoteText(wrapText(s, 60), "> "), "> ")
+	}},
+}
+
+func TestDecodeMangledPlain(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("Forwarded, quoted and rewrapped, the message still decodes.")
+	encoded, err := cipher.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	for _, tc := range mangledCorpus {
+		mangled := tc.mangle(encoded)
+		decoded, err := cipher.Decode(mangled)
+		if err != nil {
+			t.Errorf("%s: %v\n%s", tc.name, err, mangled)
+			continue
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("%s: decoded %q, want %q", tc.name, decoded, input)
+		}
+	}
+}
+
+func TestDecodeMangledNatural(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("Natural emails survive reply quoting and hard wrapping too.")
+
+	for i := 0; i < 4; i++ {
+		email, err := cipher.EncodeNatural(input)
+		if err != nil {
+			t.Fatalf("EncodeNatural failed: %v", err)
+		}
+		for _, tc := range mangledCorpus {
+			mangled := tc.mangle(email)
+			decoded, err := cipher.DecodeNatural(mangled)
+			if err != nil {
+				t.Errorf("%s: %v\n%s", tc.name, err, mangled)
+				continue
+			}
+			if !bytes.Equal(decoded, input) {
+				t.Errorf("%s: decoded %q, want %q", tc.name, decoded, input)
+			}
+		}
+	}
+}
+
+func TestDecodeMangledMarkdown(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("Markdown pasted into an email")
+	doc, err := cipher.EncodeMarkdown(input)
+	if err != nil {
+		t.Fatalf("EncodeMarkdown failed: %v", err)
+	}
+
+	for _, mangled := range []string{
+		strings.ReplaceAll(doc, "\n", "\r\n"),
+		quoteText(doc, "> "),
+		strings.ReplaceAll(doc, " ", "\u00a0"),
+	} {
+		decoded, err := cipher.DecodeMarkdown(mangled)
+		if err != nil {
+			t.Errorf("DecodeMarkdown: %v\n%s", err, mangled)
+			continue
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+	}
+}
+
+func TestDecodeNaturalConnectorCase(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("connectors retyped in another case")
+	email, _ := cipher.EncodeNatural(input)
+
+	for _, conn := range sentenceConnectors {
+		email = strings.ReplaceAll(email, conn, strings.ToLower(conn))
+	}
+	decoded, err := cipher.DecodeNatural(strings.ToUpper(email[:1]) + email[1:])
+	if err != nil {
+		t.Fatalf("DecodeNatural failed: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("decoded %q, want %q", decoded, input)
+	}
+}
+
+func TestDecodeNaturalSmartPunctuation(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("smart quotes and ellipses in the signature")
+	email, _ := cipher.EncodeNatural(input)
+
+	// Clients rewrite the greeting and signature block the user edits
+	email = strings.Replace(email, "\n\n", "\n\nSee below \u2014 it\u2019s the \u201cnotes\u201d:\n\n", 1)
+	email += "\n\u2014\nSent from my phone"
+
+	decoded, err := cipher.DecodeNatural(quoteText(email, "> "))
+	if err != nil {
+		t.Fatalf("DecodeNatural failed: %v\n%s", err, email)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("decoded %q, want %q", decoded, input)
+	}
+}
+
+func TestDecodeAllQuotedThread(t *testing.T) {
+	cipher, _ := NewCipher("normalize-key")
+	input := []byte("found inside a quoted reply")
+	encoded, _ := cipher.Encode(input)
+
+	thread := "Sounds good, see you then.\r\n\r\nOn Tuesday, Lee wrote:\r\n" +
+		strings.ReplaceAll(quoteText(wrapText(encoded, 72), "> "), "\n", "\r\n")
+
+	results := cipher.DecodeAll(thread)
+	if len(results) != 1 {
+		t.Fatalf("DecodeAll returned %d results, want 1", len(results))
+	}
+	if results[0].Err != nil {
+		t.Fatalf("DecodeAll failed: %v", results[0].Err)
+	}
+	if !bytes.Equal(results[0].Data, input) {
+		t.Errorf("decoded %q, want %q", results[0].Data, input)
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"a\r\nb\rc", "a\nb\nc"},
+		{"> a\n> > b\n>>c\n  > d", "a\nb\nc\nd"},
+		{"x\u00a0y\u202fz", "x y z"},
+		{"\ufeffa\u200bb", "ab"},
+		{"\u201cit\u2019s\u201d \u2013 ok\u2026", `"it's" - ok...`},
+		{"end\uff0e", "end."},
+	}
+	for _, tc := range tests {
+		if got := normalizeText(tc.in); got != tc.want {
+			t.Errorf("normalizeText(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -85,6 +85,22 @@ func createCipherObject(cipher *sentencecipher.Cipher) *js.Object {
 		return result
 	})
 
+	// Markdown encode/decode
+	obj.Set("encodeMarkdown", func(data []byte) string {
+		result, err := cipher.EncodeMarkdown(data)
+		if err != nil {
+			throwError(err)
+		}
+		return result
+	})
+	obj.Set("decodeMarkdown", func(encoded string) []byte {
+		result, err := cipher.DecodeMarkdown(encoded)
+		if err != nil {
+			throwError(err)
+		}
+		return result
+	})
+
 	// Alias methods (encrypt/decrypt = encodeString/decodeString)
 	obj.Set("encrypt", func(plaintext string) string {
 		result, err := cipher.EncodeString(plaintext)
@@ -157,6 +173,22 @@ func decodeNatural(encoded string) []byte {
 	return result
 }
 
+func encodeMarkdown(data []byte) string {
+	result, err := sentencecipher.EncodeMarkdown(data)
+	if err != nil {
+		throwError(err)
+	}
+	return result
+}
+
+func decodeMarkdown(encoded string) []byte {
+	result, err := sentencecipher.DecodeMarkdown(encoded)
+	if err != nil {
+		throwError(err)
+	}
+	return result
+}
+
 func main() {
 
 	// Cipher constructors
@@ -170,6 +202,8 @@ func main() {
 	exports.Set("decodeString", decodeString)
 	exports.Set("encodeNatural", encodeNatural)
 	exports.Set("decodeNatural", decodeNatural)
+	exports.Set("encodeMarkdown", encodeMarkdown)
+	exports.Set("decodeMarkdown", decodeMarkdown)
 	exports.Set("getVersion", getVersion)
 
 }
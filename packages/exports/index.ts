@@ -8,5 +8,7 @@ export const {
   decodeString,
   encodeNatural,
   decodeNatural,
+  encodeMarkdown,
+  decodeMarkdown,
   getVersion,
 } = lib;
@@ -5,6 +5,8 @@ export interface Cipher {
   decodeString(encoded: string): string;
   encodeNatural(data: Uint8Array): string;
   decodeNatural(encoded: string): Uint8Array;
+  encodeMarkdown(data: Uint8Array): string;
+  decodeMarkdown(encoded: string): Uint8Array;
   encrypt(plaintext: string): string;
   decrypt(encoded: string): string;
 }
@@ -19,3 +21,5 @@ export function encodeString(s: string): string;
 export function decodeString(encoded: string): string;
 export function encodeNatural(data: Uint8Array): string;
 export function decodeNatural(encoded: string): Uint8Array;
+export function encodeMarkdown(data: Uint8Array): string;
+export function decodeMarkdown(encoded: string): Uint8Array;
@@ -0,0 +1,132 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Padded payloads hide the true length of the compressed data:
+// - Bytes 0-1:  magic 0x50 0xAD
+// - Bytes 2-5:  payload length (big endian)
+// - Bytes 6-9:  HMAC-SHA256(key, length || payload)
+// - Then the payload followed by random bytes up to the padded size
+//
+// The length lives inside the keyed layer like the data itself, and the tag
+// tells a padded payload apart from a brotli stream that starts with the magic.
+const (
+	paddingHeaderSize = 10
+	paddingTagSize    = 4
+)
+
+var paddingMagic = []byte{0x50, 0xAD}
+
+// Padding decides how large a padded payload is
+type Padding interface {
+	// PaddedSize returns the padded size for n bytes (at least n)
+	PaddedSize(n int) int
+}
+
+// PowerOfTwoPadding pads to the next power of two, and at least to Min bytes
+type PowerOfTwoPadding struct {
+	Min int
+}
+
+// PaddedSize implements Padding
+func (p PowerOfTwoPadding) PaddedSize(n int) int {
+	size := 1
+	for size < n || size < p.Min {
+		size <<= 1
+	}
+	return size
+}
+
+// FixedPadding pads every message to Size bytes; longer messages are padded
+// to a multiple of Size
+type FixedPadding struct {
+	Size int
+}
+
+// PaddedSize implements Padding
+func (p FixedPadding) PaddedSize(n int) int {
+	if p.Size <= 0 {
+		return n
+	}
+	return (n + p.Size - 1) / p.Size * p.Size
+}
+
+// RandomPadding adds between Min and Max random bytes
+type RandomPadding struct {
+	Min, Max int
+}
+
+// PaddedSize implements Padding
+func (p RandomPadding) PaddedSize(n int) int {
+	if p.Max <= p.Min {
+		if p.Min > 0 {
+			return n + p.Min
+		}
+		return n
+	}
+	extra, err := rand.Int(rand.Reader, big.NewInt(int64(p.Max-p.Min+1)))
+	if err != nil {
+		return n + p.Max
+	}
+	return n + p.Min + int(extra.Int64())
+}
+
+// SetPadding pads the output of Encode, EncodeNatural, EncodeMarkdown and the
+// expiring variants (nil disables padding). Decoding strips padding whether
+// or not a policy is set.
+func (c *Cipher) SetPadding(p Padding) {
+	c.padding = p
+}
+
+// padPayload wraps compressed data in the padding layer when a policy is set (internal use)
+func (c *Cipher) padPayload(payload []byte) ([]byte, error) {
+	if c.padding == nil {
+		return payload, nil
+	}
+	size := c.padding.PaddedSize(paddingHeaderSize + len(payload))
+	if size < paddingHeaderSize+len(payload) {
+		return nil, errors.New("padding policy returned a size smaller than the message")
+	}
+
+	out := make([]byte, size)
+	copy(out, paddingMagic)
+	binary.BigEndian.PutUint32(out[2:6], uint32(len(payload)))
+	copy(out[6:paddingHeaderSize], c.paddingTag(out[2:6], payload))
+	copy(out[paddingHeaderSize:], payload)
+	if _, err := rand.Read(out[paddingHeaderSize+len(payload):]); err != nil {
+		return nil, fmt.Errorf("padding generation failed: %w", err)
+	}
+	return out, nil
+}
+
+// unpadPayload strips the padding layer, returning raw unchanged when it has none (internal use)
+func (c *Cipher) unpadPayload(raw []byte) []byte {
+	if len(raw) < paddingHeaderSize || !bytes.Equal(raw[:2], paddingMagic) {
+		return raw
+	}
+	length := binary.BigEndian.Uint32(raw[2:6])
+	if uint64(length) > uint64(len(raw)-paddingHeaderSize) {
+		return raw
+	}
+	payload := raw[paddingHeaderSize : paddingHeaderSize+int(length)]
+	if !hmac.Equal(raw[6:paddingHeaderSize], c.paddingTag(raw[2:6], payload)) {
+		return raw
+	}
+	return payload
+}
+
+func (c *Cipher) paddingTag(length, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte("sentence-cipher/padding:"+c.key))
+	mac.Write(length)
+	mac.Write(payload)
+	return mac.Sum(nil)[:paddingTagSize]
+}
@@ -0,0 +1,106 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPaddingPolicies(t *testing.T) {
+	tests := []struct {
+		policy Padding
+		n      int
+		want   int
+	}{
+		{PowerOfTwoPadding{}, 1, 1},
+		{PowerOfTwoPadding{}, 33, 64},
+		{PowerOfTwoPadding{Min: 128}, 33, 128},
+		{PowerOfTwoPadding{Min: 16}, 200, 256},
+		{FixedPadding{Size: 100}, 10, 100},
+		{FixedPadding{Size: 100}, 100, 100},
+		{FixedPadding{Size: 100}, 101, 200},
+		{FixedPadding{}, 42, 42},
+		{RandomPadding{Min: 5, Max: 5}, 10, 15},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.PaddedSize(tt.n); got != tt.want {
+			t.Errorf("%#v.PaddedSize(%d) = %d, want %d", tt.policy, tt.n, got, tt.want)
+		}
+	}
+
+	for i := 0; i < 50; i++ {
+		got := RandomPadding{Min: 8, Max: 24}.PaddedSize(10)
+		if got < 18 || got > 34 {
+			t.Fatalf("RandomPadding size %d outside [18, 34]", got)
+		}
+	}
+}
+
+func TestPaddingHidesLength(t *testing.T) {
+	cipher, _ := NewCipher("padding-key")
+	cipher.SetPadding(FixedPadding{Size: 96})
+
+	short := []byte("ok")
+	long := []byte("The quarterly numbers are in and they look considerably better than expected")
+
+	shortText, err := cipher.Encode(short)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	longText, err := cipher.Encode(long)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if a, b := len(splitSentences(shortText)), len(splitSentences(longText)); a != b {
+		t.Errorf("padded messages have %d and %d sentences, want equal", a, b)
+	}
+
+	for _, tc := range []struct {
+		text string
+		want []byte
+	}{{shortText, short}, {longText, long}} {
+		decoded, err := cipher.Decode(tc.text)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if !bytes.Equal(decoded, tc.want) {
+			t.Errorf("decoded %q, want %q", decoded, tc.want)
+		}
+	}
+}
+
+func TestPaddingAllModes(t *testing.T) {
+	cipher, _ := NewCipher("padding-key")
+	cipher.SetPadding(PowerOfTwoPadding{Min: 64})
+	input := []byte("Padding works in every mode")
+
+	natural, err := cipher.EncodeNatural(input)
+	if err != nil {
+		t.Fatalf("EncodeNatural error: %v", err)
+	}
+	if decoded, err := cipher.DecodeNatural(natural); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeNatural = %q, %v", decoded, err)
+	}
+
+	markdown, err := cipher.EncodeMarkdown(input)
+	if err != nil {
+		t.Fatalf("EncodeMarkdown error: %v", err)
+	}
+	if decoded, err := cipher.DecodeMarkdown(markdown); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeMarkdown = %q, %v", decoded, err)
+	}
+
+	expiring, err := cipher.EncodeExpiring(input, time.Hour)
+	if err != nil {
+		t.Fatalf("EncodeExpiring error: %v", err)
+	}
+	if decoded, err := cipher.Decode(expiring); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("Decode of expiring message = %q, %v", decoded, err)
+	}
+
+	// A receiver without a policy strips padding too
+	receiver, _ := NewCipher("padding-key")
+	if decoded, err := receiver.DecodeNatural(natural); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeNatural without policy = %q, %v", decoded, err)
+	}
+}
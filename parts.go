@@ -0,0 +1,171 @@
+package sentencecipher
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Common character budgets for EncodeParts
+const (
+	SMSLength       = 160 // single GSM-7 SMS
+	MicroblogLength = 280 // microblog post
+)
+
+// Each part starts with a 2-byte marker (part number, total parts) encoded as
+// the first words of the part, followed by a chunk of the compressed payload.
+const partMarkerSize = 2
+
+// maxParts is the largest total that fits in the one-byte marker field
+const maxParts = 255
+
+// MissingPartsError is returned by Reassemble when some parts are absent
+type MissingPartsError struct {
+	Missing []int // 1-based part numbers
+	Total   int
+}
+
+func (e *MissingPartsError) Error() string {
+	nums := make([]string, len(e.Missing))
+	for i, n := range e.Missing {
+		nums[i] = strconv.Itoa(n)
+	}
+	return fmt.Sprintf("missing parts %s of %d", strings.Join(nums, ", "), e.Total)
+}
+
+// EncodeParts compresses data then splits it into parts whose encoded text
+// each fits within maxChars characters (e.g. SMSLength or MicroblogLength)
+func (c *Cipher) EncodeParts(data []byte, maxChars int) ([]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+	return c.encodePartsRaw(compressed, maxChars)
+}
+
+// encodePartsRaw splits already-compressed data into marked parts (internal use)
+func (c *Cipher) encodePartsRaw(data []byte, maxChars int) ([]string, error) {
+	// The marker words depend on the total, so re-split until the total is stable
+	total := 1
+	for attempt := 0; attempt < 8; attempt++ {
+		parts, err := c.splitParts(data, maxChars, total)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == total {
+			return parts, nil
+		}
+		total = len(parts)
+		if total > maxParts {
+			return nil, fmt.Errorf("payload needs %d parts, at most %d are supported", total, maxParts)
+		}
+	}
+	return nil, errors.New("could not find a stable part count")
+}
+
+// splitParts greedily fills each part with as many bytes as fit in maxChars
+func (c *Cipher) splitParts(data []byte, maxChars int, total int) ([]string, error) {
+	var parts []string
+	pos := 0
+	for pos < len(data) {
+		num := len(parts) + 1
+		if num > maxParts {
+			return nil, fmt.Errorf("payload needs more than %d parts", maxParts)
+		}
+		marker := []byte{byte(num), byte(total)}
+
+		best := ""
+		n := 0
+		for pos+n < len(data) {
+			chunk := append(append([]byte{}, marker...), data[pos:pos+n+1]...)
+			text := c.encodeRaw(chunk)
+			if utf8.RuneCountInString(text) > maxChars {
+				break
+			}
+			best = text
+			n++
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("maxChars %d is too small to hold a single part", maxChars)
+		}
+
+		parts = append(parts, best)
+		pos += n
+	}
+	return parts, nil
+}
+
+// Reassemble decodes parts produced by EncodeParts, in any order, and
+// returns the original data. Duplicate parts are ignored.
+func (c *Cipher) Reassemble(parts []string) ([]byte, error) {
+	compressed, err := c.reassembleRaw(parts)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed: %w", err)
+	}
+	return decompressed, nil
+}
+
+// reassembleRaw orders and joins part chunks without decompression (internal use)
+func (c *Cipher) reassembleRaw(parts []string) ([]byte, error) {
+	if len(parts) == 0 {
+		return nil, errors.New("no parts provided")
+	}
+
+	total := 0
+	chunks := make(map[int][]byte)
+	for _, part := range parts {
+		raw, err := c.decodeRaw(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		if len(raw) <= partMarkerSize {
+			return nil, errors.New("part too short to carry a marker")
+		}
+
+		num, partTotal := int(raw[0]), int(raw[1])
+		if partTotal == 0 || num == 0 || num > partTotal {
+			return nil, fmt.Errorf("invalid part marker %d/%d", num, partTotal)
+		}
+		if total == 0 {
+			total = partTotal
+		} else if partTotal != total {
+			return nil, fmt.Errorf("parts disagree on total: %d vs %d", total, partTotal)
+		}
+		chunks[num] = raw[partMarkerSize:]
+	}
+
+	var missing []int
+	for num := 1; num <= total; num++ {
+		if _, ok := chunks[num]; !ok {
+			missing = append(missing, num)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingPartsError{Missing: missing, Total: total}
+	}
+
+	var result []byte
+	for num := 1; num <= total; num++ {
+		result = append(result, chunks[num]...)
+	}
+	return result, nil
+}
+
+// EncodeParts compresses then splits into length-limited parts (package-level)
+func EncodeParts(data []byte, maxChars int) ([]string, error) {
+	return NewDefaultCipher().EncodeParts(data, maxChars)
+}
+
+// Reassemble joins parts then decompresses (package-level)
+func Reassemble(parts []string) ([]byte, error) {
+	return NewDefaultCipher().Reassemble(parts)
+}
@@ -0,0 +1,78 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodePartsReassemble(t *testing.T) {
+	input := []byte(strings.Repeat("Meet me at the north entrance of the station at 9pm sharp. ", 4) + "สวัสดี")
+
+	for _, maxChars := range []int{SMSLength, MicroblogLength, 1000} {
+		cipher, _ := NewCipher("sms-key")
+		parts, err := cipher.EncodeParts(input, maxChars)
+		if err != nil {
+			t.Fatalf("EncodeParts(%d) error: %v", maxChars, err)
+		}
+		for i, part := range parts {
+			if n := utf8.RuneCountInString(part); n > maxChars {
+				t.Errorf("part %d is %d chars, budget %d", i+1, n, maxChars)
+			}
+		}
+
+		// Reverse order and add a duplicate
+		shuffled := make([]string, 0, len(parts)+1)
+		for i := len(parts) - 1; i >= 0; i-- {
+			shuffled = append(shuffled, parts[i])
+		}
+		shuffled = append(shuffled, parts[0])
+
+		decoded, err := cipher.Reassemble(shuffled)
+		if err != nil {
+			t.Fatalf("Reassemble(%d) error: %v", maxChars, err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("Reassemble mismatch for budget %d\noriginal: %q\ndecoded:  %q", maxChars, input, decoded)
+		}
+		t.Logf("budget %d: %d parts", maxChars, len(parts))
+	}
+}
+
+func TestReassembleMissingParts(t *testing.T) {
+	// Incompressible data so the payload spans several parts
+	input := make([]byte, 120)
+	for i := range input {
+		input[i] = byte(i*131 + i*i*7)
+	}
+	parts, err := EncodeParts(input, SMSLength)
+	if err != nil {
+		t.Fatalf("EncodeParts error: %v", err)
+	}
+	if len(parts) < 4 {
+		t.Fatalf("expected at least 4 parts, got %d", len(parts))
+	}
+
+	partial := append([]string{parts[0]}, parts[3:]...)
+	_, err = Reassemble(partial)
+
+	var missingErr *MissingPartsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expected MissingPartsError, got %v", err)
+	}
+	if !reflect.DeepEqual(missingErr.Missing, []int{2, 3}) {
+		t.Errorf("Missing = %v, want [2 3]", missingErr.Missing)
+	}
+	if !strings.Contains(err.Error(), "2, 3") {
+		t.Errorf("error should name missing parts: %v", err)
+	}
+}
+
+func TestEncodePartsTooSmall(t *testing.T) {
+	if _, err := EncodeParts([]byte("hello"), 10); err == nil {
+		t.Error("expected error for a budget that cannot hold a part")
+	}
+}
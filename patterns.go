@@ -0,0 +1,641 @@
+package sentencecipher
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Every sentence is written from a Pattern. A cipher uses a set of them: some
+// for 1-byte sentences, some for 2-byte sentences (picked at random) and a
+// power of two of full (3-byte) patterns, the structures, whose choice carries
+// more bits. All modes share the same rotation (layout.go) and the same slot
+// lookup (Vocabulary).
+
+// Slot is a word position of a pattern
+type Slot byte
+
+const (
+	SlotSubject    Slot = 'S' // name, carries the first byte
+	SlotVerb       Slot = 'V' // verb ("reviews"), carries the second byte
+	SlotVerbBase   Slot = 'B' // the verb's base form ("review")
+	SlotParticiple Slot = 'P' // the verb's past participle ("reviewed")
+	SlotObject     Slot = 'O' // object, carries the third byte
+	SlotIndirect   Slot = 'I' // name carrying no data (see EncodeDeniable)
+)
+
+// Slots holds the word-list indices of a sentence (-1 for slots it lacks)
+type Slots struct {
+	Subject, Verb, Indirect, Object int
+
+	ending int // index of a template's filler ending in the key's pool (fillers.go)
+}
+
+// Pattern is a sentence shape. A pattern of capacity 1 has a subject slot,
+// capacity 2 adds a verb slot (in any form) and capacity 3 an object and an
+// indirect object slot.
+type Pattern interface {
+	// Slots lists the word slots in sentence order
+	Slots() []Slot
+	// Capacity returns the data bytes a sentence carries (1 to 3)
+	Capacity() int
+	// Render writes the sentence for s with v's words, lowercase and with its
+	// final punctuation
+	Render(v Vocabulary, s Slots) string
+	// Parse matches a sentence at the start of lowercase words (without the
+	// final punctuation) and returns its slots and the number of words used
+	// (0 when it does not match)
+	Parse(v Vocabulary, words []string) (Slots, int)
+}
+
+// Vocabulary gives patterns a cipher's word lists
+type Vocabulary struct {
+	c *Cipher
+}
+
+// Word returns the word with index i for slot
+func (v Vocabulary) Word(slot Slot, i int) string {
+	switch slot {
+	case SlotSubject, SlotIndirect:
+		return v.c.names[i]
+	case SlotVerb:
+		return v.c.verbs[i]
+	case SlotVerbBase:
+		return verbBaseForm(v.c.verbs[i])
+	case SlotParticiple:
+		return verbParticiple(v.c.verbs[i])
+	case SlotObject:
+		return v.c.objects[i]
+	}
+	return ""
+}
+
+// Match matches the first word (or the first two, for words a speech
+// recognizer splits like "double checks") against the words for slot,
+// allowing spelling variants. It returns the index and the number of words
+// used (0 for no match).
+func (v Vocabulary) Match(slot Slot, words []string) (int, int) {
+	if len(words) == 0 {
+		return -1, 0
+	}
+	var list []string
+	var form func(string) string
+	switch slot {
+	case SlotSubject, SlotIndirect:
+		list = v.c.names
+	case SlotVerb:
+		list = v.c.verbs
+	case SlotVerbBase:
+		list, form = v.c.verbs, verbBaseForm
+	case SlotParticiple:
+		list, form = v.c.verbs, verbParticiple
+	case SlotObject:
+		list = v.c.objects
+	default:
+		return -1, 0
+	}
+	find := func(word string) int {
+		if form == nil {
+			return findIndex(list, word)
+		}
+		for i, w := range list {
+			if form(w) == word {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if i := find(words[0]); i != -1 {
+		return i, 1
+	}
+	if variant, ok := spokenVariants[words[0]]; ok {
+		if i := find(variant); i != -1 {
+			return i, 1
+		}
+	}
+	if len(words) > 1 {
+		for _, joined := range []string{words[0] + "-" + words[1], words[0] + words[1]} {
+			if i := find(joined); i != -1 {
+				return i, 2
+			}
+		}
+	}
+	return -1, 0
+}
+
+// templatePattern is a Pattern written as a template of slot letters (see
+// Slot), W for a work ending, D for a daily ending (fillers.go) and literal
+// words, ending in "?" for a question
+type templatePattern struct {
+	tokens   []string
+	question bool
+}
+
+// NewTemplatePattern builds a pattern from a template such as
+// "S V the O for I" or "can S B the O for I?"
+func NewTemplatePattern(template string) (Pattern, error) {
+	template = strings.TrimSpace(template)
+	pat := &templatePattern{
+		tokens:   strings.Fields(strings.TrimSuffix(template, "?")),
+		question: strings.HasSuffix(template, "?"),
+	}
+	for _, tok := range pat.tokens {
+		if isTemplateSlot(tok) {
+			if !strings.Contains("SVBPOIWD", tok) {
+				return nil, fmt.Errorf("pattern %q: unknown slot %q", template, tok)
+			}
+		} else if tok != strings.ToLower(tok) || strings.ContainsAny(tok, ".?,") {
+			return nil, fmt.Errorf("pattern %q: invalid word %q", template, tok)
+		}
+	}
+	if err := checkPatternSlots(pat); err != nil {
+		return nil, fmt.Errorf("pattern %q: %w", template, err)
+	}
+	return pat, nil
+}
+
+// isTemplateSlot reports whether a template token is a slot letter rather
+// than a literal word
+func isTemplateSlot(tok string) bool {
+	return len(tok) == 1 && tok[0] >= 'A' && tok[0] <= 'Z'
+}
+
+func mustTemplatePattern(template string) Pattern {
+	pat, err := NewTemplatePattern(template)
+	if err != nil {
+		panic(err)
+	}
+	return pat
+}
+
+func (pat *templatePattern) Slots() []Slot {
+	var slots []Slot
+	for _, tok := range pat.tokens {
+		if len(tok) == 1 && strings.Contains("SVBPOI", tok) {
+			slots = append(slots, Slot(tok[0]))
+		}
+	}
+	return slots
+}
+
+func (pat *templatePattern) Capacity() int {
+	n := 0
+	for _, slot := range pat.Slots() {
+		if slot != SlotIndirect {
+			n++
+		}
+	}
+	return n
+}
+
+func (pat *templatePattern) Render(v Vocabulary, s Slots) string {
+	words := make([]string, 0, len(pat.tokens))
+	for _, tok := range pat.tokens {
+		switch tok {
+		case "S":
+			words = append(words, v.Word(SlotSubject, s.Subject))
+		case "I":
+			words = append(words, v.Word(SlotIndirect, s.Indirect))
+		case "V", "B", "P":
+			words = append(words, v.Word(Slot(tok[0]), s.Verb))
+		case "O":
+			words = append(words, v.Word(SlotObject, s.Object))
+		case "W":
+			words = append(words, v.c.workEnding(s.ending))
+		case "D":
+			words = append(words, v.c.dailyEnding(s.ending))
+		default:
+			words = append(words, tok)
+		}
+	}
+	if pat.question {
+		return strings.Join(words, " ") + "?"
+	}
+	return strings.Join(words, " ") + "."
+}
+
+func (pat *templatePattern) Parse(v Vocabulary, words []string) (Slots, int) {
+	s := Slots{Subject: -1, Verb: -1, Indirect: -1, Object: -1, ending: -1}
+	n := 0
+	for _, tok := range pat.tokens {
+		rest := words[n:]
+		idx, m := -1, 0
+		switch tok {
+		case "W":
+			if m = matchWorkEnding(rest); m > 0 {
+				s.ending = v.c.fillerIndex(workEndings, strings.Join(rest[:m], " "))
+			}
+		case "D":
+			if len(rest) > 0 && isDailyEnding(rest[0]) {
+				m = 1
+				s.ending = v.c.fillerIndex(dailyEndings, rest[0])
+			}
+		case "S", "V", "B", "P", "O", "I":
+			idx, m = v.Match(Slot(tok[0]), rest)
+		default:
+			if len(rest) > 0 && rest[0] == tok {
+				m = 1
+			}
+		}
+		if m == 0 {
+			return s, 0
+		}
+		switch tok {
+		case "S":
+			s.Subject = idx
+		case "I":
+			s.Indirect = idx
+		case "V", "B", "P":
+			s.Verb = idx
+		case "O":
+			s.Object = idx
+		}
+		n += m
+	}
+	return s, n
+}
+
+// hasEnding reports whether the template ends a sentence in a filler
+func (pat *templatePattern) hasEnding() bool {
+	for _, tok := range pat.tokens {
+		if tok == "W" || tok == "D" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnding reports whether p is a template with a filler ending
+func hasEnding(p Pattern) bool {
+	pat, ok := p.(*templatePattern)
+	return ok && pat.hasEnding()
+}
+
+// literals returns the literal words of the template
+func (pat *templatePattern) literals() []string {
+	var words []string
+	for _, tok := range pat.tokens {
+		if !isTemplateSlot(tok) {
+			words = append(words, tok)
+		}
+	}
+	return words
+}
+
+// checkPatternSlots checks that the slots of p agree with its capacity
+func checkPatternSlots(p Pattern) error {
+	count := make(map[Slot]int)
+	for _, slot := range p.Slots() {
+		if slot == SlotVerbBase || slot == SlotParticiple {
+			slot = SlotVerb
+		}
+		count[slot]++
+	}
+	for _, n := range count {
+		if n > 1 {
+			return errors.New("a slot appears twice")
+		}
+	}
+	want := [][4]int{ // subject, verb, object, indirect
+		1: {1, 0, 0, 0},
+		2: {1, 1, 0, 0},
+		3: {1, 1, 1, 1},
+	}
+	c := p.Capacity()
+	if c < 1 || c > 3 || want[c] != [4]int{count[SlotSubject], count[SlotVerb], count[SlotObject], count[SlotIndirect]} {
+		return fmt.Errorf("slots do not fit a capacity of %d", c)
+	}
+	return nil
+}
+
+var patterns = struct {
+	sync.RWMutex
+	byName map[string]Pattern
+}{byName: make(map[string]Pattern)}
+
+// builtinPatterns are the patterns of every cipher unless SetPatterns is
+// called: the short patterns, then the structures. The first structure is the
+// original "S V IO O" sentence.
+var builtinPatterns = []struct{ name, template string }{
+	{"works", "S W"},
+	{"daily", "S V D"},
+	{"active", "S V I O"},
+	{"for", "S V the O for I"},
+	{"with", "S V the O with I"},
+	{"together", "S and I B the O"},
+	{"passive-for", "the O will be P by S for I"},
+	{"passive-and", "the O will be P by S and I"},
+	{"question-for", "can S B the O for I?"},
+	{"question", "will S B I the O?"},
+}
+
+// markdownActionPatterns write Markdown action items (see markdown.go)
+var markdownActionPatterns = []struct{ name, template string }{
+	{"action-follow-up", "S to follow up"},
+	{"action-daily", "S to B D"},
+	{"action", "S to B O with I"},
+}
+
+var defaultPatterns, actionPatterns *patternSet
+
+func init() {
+	var names, actions []string
+	for _, b := range builtinPatterns {
+		mustRegisterPattern(b.name, mustTemplatePattern(b.template))
+		names = append(names, b.name)
+	}
+	for _, b := range markdownActionPatterns {
+		mustRegisterPattern(b.name, mustTemplatePattern(b.template))
+		actions = append(actions, b.name)
+	}
+	var err error
+	if defaultPatterns, err = newPatternSet(names); err != nil {
+		panic(err)
+	}
+	if actionPatterns, err = newPatternSet(actions); err != nil {
+		panic(err)
+	}
+}
+
+func mustRegisterPattern(name string, p Pattern) {
+	if err := RegisterPattern(name, p); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterPattern adds a pattern (or replaces one with the same name) so that
+// SetPatterns can select it. Ciphers that already selected a pattern keep
+// the one they selected.
+func RegisterPattern(name string, p Pattern) error {
+	if name == "" {
+		return errors.New("pattern name is required")
+	}
+	if err := checkPatternSlots(p); err != nil {
+		return fmt.Errorf("pattern %s: %w", name, err)
+	}
+	patterns.Lock()
+	defer patterns.Unlock()
+	patterns.byName[name] = p
+	return nil
+}
+
+// LookupPattern returns a registered pattern
+func LookupPattern(name string) (Pattern, bool) {
+	patterns.RLock()
+	defer patterns.RUnlock()
+	p, ok := patterns.byName[name]
+	return p, ok
+}
+
+// unregisterPattern removes a registered pattern (tests clean up with it)
+func unregisterPattern(name string) {
+	patterns.Lock()
+	defer patterns.Unlock()
+	delete(patterns.byName, name)
+}
+
+// PatternNames returns the registered pattern names in alphabetical order
+func PatternNames() []string {
+	patterns.RLock()
+	defer patterns.RUnlock()
+	names := make([]string, 0, len(patterns.byName))
+	for name := range patterns.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isPatternLiteral reports whether word is a literal of a registered template
+// pattern or of a grammar in use, which no word list may contain
+func isPatternLiteral(word string) bool {
+	grammarLiterals.RLock()
+	inGrammar := grammarLiterals.words[word]
+	grammarLiterals.RUnlock()
+	if inGrammar {
+		return true
+	}
+	patterns.RLock()
+	defer patterns.RUnlock()
+	for _, p := range patterns.byName {
+		if pat, ok := p.(*templatePattern); ok {
+			for _, lit := range pat.literals() {
+				if lit == word {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// patternSet is the patterns a cipher writes and reads
+type patternSet struct {
+	short      [3][]Pattern // by capacity (1 and 2), used at random
+	full       []Pattern    // the structures
+	bits       int          // carried by the choice of structure
+	endingBits int          // carried by the filler ending of a short sentence
+}
+
+func newPatternSet(names []string) (*patternSet, error) {
+	var list []Pattern
+	for _, name := range names {
+		p, ok := LookupPattern(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown pattern: %s", name)
+		}
+		list = append(list, p)
+	}
+	return groupPatterns(list)
+}
+
+// groupPatterns sorts patterns by capacity into a set
+func groupPatterns(list []Pattern) (*patternSet, error) {
+	set := &patternSet{}
+	for _, p := range list {
+		if c := p.Capacity(); c == 3 {
+			set.full = append(set.full, p)
+		} else {
+			set.short[c] = append(set.short[c], p)
+		}
+	}
+	if len(set.short[1]) == 0 || len(set.short[2]) == 0 || len(set.full) == 0 {
+		return nil, errors.New("patterns must include capacities 1, 2 and 3")
+	}
+	for 1<<set.bits < len(set.full) {
+		set.bits++
+	}
+	if 1<<set.bits != len(set.full) {
+		return nil, fmt.Errorf("%d patterns of capacity 3, want a power of two", len(set.full))
+	}
+	// Short sentences carry bits in their endings when every short pattern has one
+	set.endingBits = fillerBits
+	for _, list := range set.short[1:] {
+		for _, p := range list {
+			if !hasEnding(p) {
+				set.endingBits = 0
+			}
+		}
+	}
+	return set, nil
+}
+
+// SetPatterns selects the registered patterns the cipher writes and reads:
+// at least one of capacity 1 and one of capacity 2 (used at random) and a
+// power of two of capacity 3. The order of the latter matters: both sides
+// must list them alike, and the first is used alone by messages that need no
+// structure bits. Every pattern is checked to parse back unambiguously with
+// the cipher's word lists, and template patterns not to be confused with one
+// another in any theme.
+func (c *Cipher) SetPatterns(names ...string) error {
+	set, err := newPatternSet(names)
+	if err != nil {
+		return err
+	}
+	if err := checkShapes(set.templates()); err != nil {
+		return fmt.Errorf("ambiguous patterns: %w", err)
+	}
+	if err := set.verify(c); err != nil {
+		return err
+	}
+	c.patterns = set
+	return nil
+}
+
+// patternSet returns the patterns the cipher uses
+func (c *Cipher) patternSet() *patternSet {
+	if c.patterns == nil {
+		return defaultPatterns
+	}
+	return c.patterns
+}
+
+// templates returns the set's template patterns, which can be checked for
+// ambiguity word class by word class
+func (set *patternSet) templates() []*templatePattern {
+	var out []*templatePattern
+	for _, list := range append(set.short[1:], set.full) {
+		for _, p := range list {
+			if pat, ok := p.(*templatePattern); ok {
+				out = append(out, pat)
+			}
+		}
+	}
+	return out
+}
+
+// verify renders sample sentences of every pattern with w's word lists and
+// checks that they parse back to the same pattern and slots
+func (set *patternSet) verify(w *Cipher) error {
+	for _, size := range []int{1, 2, 3} {
+		structures := len(set.full)
+		if size < 3 {
+			structures = len(set.short[size])
+		}
+		for structure := 0; structure < structures; structure++ {
+			for _, i := range []int{0, 1, 85, 170, 255} {
+				want := sentenceParse{s: i, v: -1, io: -1, o: -1, ending: -1, size: size, structure: structure}
+				if hasEnding(set.pattern(want)) {
+					want.ending = i % fillerPoolSize
+				}
+				if size > 1 {
+					want.v = (i + 7) % 256
+				}
+				if size > 2 {
+					want.io, want.o = (i+13)%256, (i+29)%256
+				}
+				text := set.render(w, want)
+				got, ok := set.parse(w, strings.Fields(trimSentenceEnd(text)))
+				if !ok || got != want {
+					return fmt.Errorf("ambiguous patterns: %q does not parse back", text)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sentenceParse holds the word-list indices of one sentence (-1 for slots
+// its pattern does not have) and which pattern of its set it uses
+type sentenceParse struct {
+	s, v, io, o int
+	ending      int // filler ending in the key's pool (-1 for none or another key's)
+	size        int // data bytes in the slots: 1, 2 or 3
+	structure   int // index in the set's patterns of its size
+}
+
+func (p sentenceParse) slots() Slots {
+	return Slots{Subject: p.s, Verb: p.v, Indirect: p.io, Object: p.o, ending: p.ending}
+}
+
+// pattern returns the pattern p uses
+func (set *patternSet) pattern(p sentenceParse) Pattern {
+	if p.size < 3 {
+		return set.short[p.size][p.structure]
+	}
+	return set.full[p.structure]
+}
+
+// render writes the sentence p with w's word lists
+func (set *patternSet) render(w *Cipher, p sentenceParse) string {
+	return set.pattern(p).Render(Vocabulary{w}, p.slots())
+}
+
+// match parses the sentence at the start of words with w's word lists, using
+// the pattern that matches the most words
+func (set *patternSet) match(w *Cipher, words []string) (sentenceParse, int) {
+	var best sentenceParse
+	bestN := 0
+	try := func(pat Pattern, structure int) {
+		if s, n := pat.Parse(Vocabulary{w}, words); n > bestN {
+			best = sentenceParse{s: s.Subject, v: s.Verb, io: s.Indirect, o: s.Object, ending: -1, size: pat.Capacity(), structure: structure}
+			if hasEnding(pat) {
+				best.ending = s.ending
+			}
+			bestN = n
+		}
+	}
+	for _, list := range [][]Pattern{set.short[1], set.short[2], set.full} {
+		for i, pat := range list {
+			try(pat, i)
+		}
+	}
+	return best, bestN
+}
+
+// parse parses all the words of one sentence
+func (set *patternSet) parse(w *Cipher, words []string) (sentenceParse, bool) {
+	p, n := set.match(w, words)
+	return p, n > 0 && n == len(words)
+}
+
+// render writes p with the cipher's patterns and word lists
+func (p sentenceParse) render(w *Cipher) string {
+	return w.patternSet().render(w, p)
+}
+
+// matchSentence parses the sentence at the start of words with c's patterns
+// and word lists
+func (c *Cipher) matchSentence(words []string) (sentenceParse, int) {
+	return c.patternSet().match(c, words)
+}
+
+// parseSentence parses the lowercase words of one sentence (without its final
+// punctuation)
+func (c *Cipher) parseSentence(words []string) (sentenceParse, bool) {
+	return c.patternSet().parse(c, words)
+}
+
+// sentenceError explains why words do not parse with c's word lists
+func (c *Cipher) sentenceError(words []string) error {
+	if len(words) < 2 {
+		return errors.New("invalid sentence: too few words")
+	}
+	if findIndex(c.names, words[0]) == -1 && !isPatternLiteral(words[0]) {
+		return errors.New("unknown name: " + words[0])
+	}
+	return errors.New("unrecognized sentence pattern: " + strings.Join(words, " "))
+}
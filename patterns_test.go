@@ -0,0 +1,272 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestSentenceStructuresParse(t *testing.T) {
+	ciphers := []*Cipher{NewDefaultCipher(), NewThemedCipher("k", "tech"), NewThemedCipher("k", "business")}
+	spoken := NewDefaultCipher()
+	spoken.SetSpokenVocabulary(true)
+	ciphers = append(ciphers, spoken)
+
+	for _, c := range ciphers {
+		for structure := range c.patternSet().full {
+			for idx := 0; idx < 256; idx++ {
+				want := sentenceParse{s: idx, v: (idx + 1) % 256, io: (idx + 2) % 256, o: (idx + 3) % 256, ending: -1, size: 3, structure: structure}
+				text := want.render(c)
+				got, ok := c.parseSentence(strings.Fields(trimSentenceEnd(text)))
+				if !ok || got != want {
+					t.Fatalf("%q parsed as %+v (%v), want %+v", text, got, ok, want)
+				}
+			}
+		}
+	}
+}
+
+func TestVerbFormsAreUnique(t *testing.T) {
+	spoken := NewDefaultCipher()
+	spoken.SetSpokenVocabulary(true)
+	lists := map[string][]string{"default": defaultVerbs, "spoken": spoken.verbs}
+	for _, name := range ThemeNames() {
+		rt, _ := lookupTheme(name)
+		lists[name] = rt.Verbs
+	}
+	for name, verbs := range lists {
+		if v, ok := checkVerbForms(verbs); !ok {
+			t.Errorf("%s: verb %q shares a base form or participle", name, v)
+		}
+	}
+
+	for verb, want := range map[string]string{
+		"reviews": "reviewed", "analyzes": "analyzed", "clarifies": "clarified",
+		"drops": "dropped", "builds": "built", "caches": "cached", "focuses": "focused",
+	} {
+		if got := verbParticiple(verb); got != want {
+			t.Errorf("verbParticiple(%q) = %q, want %q", verb, got, want)
+		}
+	}
+}
+
+func TestPlanRoundTrip(t *testing.T) {
+	for n := 0; n < 100; n++ {
+		data := make([]byte, n)
+		rand.Read(data)
+		for _, endingBits := range []int{0, fillerBits} {
+			got, err := joinPlans(planSentences(data, 3, endingBits), 3, endingBits)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Fatalf("%d bytes, %d ending bits: joinPlans = %x, %v, want %x", n, endingBits, got, err, data)
+			}
+		}
+	}
+}
+
+func TestStructuresAddCapacity(t *testing.T) {
+	data := bytes.Repeat([]byte{0xFF}, 81)
+	plans := planSentences(data, 3, 0)
+	if len(plans) != 24 {
+		t.Errorf("81 bytes took %d sentences, want 24", len(plans))
+	}
+
+	cipher, _ := NewCipher("structure-key")
+	text := cipher.encodeRaw(data)
+	if n := len(splitSentences(text)); n != 24 {
+		t.Errorf("encodeRaw wrote %d sentences, want 24", n)
+	}
+	if !strings.Contains(text, "?") {
+		t.Error("no question among the structures")
+	}
+	decoded, err := cipher.decodeRaw(text)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("decodeRaw = %x, %v", decoded, err)
+	}
+}
+
+func TestLegacyLayoutDecodes(t *testing.T) {
+	// Text written before structures existed: "S V IO O." sentences, 3 bytes each
+	cipher, _ := NewCipher("legacy-key")
+	data := []byte("written byte by byte")
+	var sentences []string
+	pos := 0
+	for _, plan := range bytePlans(data) {
+		p := plan.slots(pos)
+		p.io = 0
+		sentences = append(sentences, p.render(cipher))
+		pos += p.size
+	}
+
+	decoded, err := cipher.decodeRaw(strings.Join(sentences, " "))
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("decodeRaw = %q, %v", decoded, err)
+	}
+}
+
+func TestNaturalQuestionsRoundTrip(t *testing.T) {
+	cipher, _ := NewCipher("question-key")
+	input := []byte("Questions and passive sentences keep the email varied")
+	for i := 0; i < 5; i++ {
+		email, err := cipher.EncodeNatural(input)
+		if err != nil {
+			t.Fatalf("EncodeNatural error: %v", err)
+		}
+		decoded, err := cipher.DecodeNatural(email)
+		if err != nil || !bytes.Equal(decoded, input) {
+			t.Fatalf("DecodeNatural = %q, %v\n%s", decoded, err, email)
+		}
+		for _, conn := range sentenceConnectors {
+			for _, literal := range []string{"The", "Can", "Will"} {
+				if strings.Contains(email, conn+" "+literal+" ") {
+					t.Errorf("connector before a literal start %q:\n%s", conn+" "+literal, email)
+				}
+			}
+		}
+	}
+}
+
+func TestRegisterThemeRejectsClashingVerbForms(t *testing.T) {
+	bad := testTheme("clashing")
+	bad.Verbs[0], bad.Verbs[1] = "logs", "log"
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for verbs sharing a base form")
+	}
+	bad = testTheme("clashing")
+	bad.Objects[0] = "the"
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for a sentence pattern word")
+	}
+}
+
+// politePattern is a third-party Pattern: "S should B the O with I."
+type politePattern struct{}
+
+func (politePattern) Slots() []Slot {
+	return []Slot{SlotSubject, SlotVerbBase, SlotObject, SlotIndirect}
+}
+
+func (politePattern) Capacity() int { return 3 }
+
+func (politePattern) Render(v Vocabulary, s Slots) string {
+	return v.Word(SlotSubject, s.Subject) + " should " + v.Word(SlotVerbBase, s.Verb) + " the " +
+		v.Word(SlotObject, s.Object) + " with " + v.Word(SlotIndirect, s.Indirect) + "."
+}
+
+func (politePattern) Parse(v Vocabulary, words []string) (Slots, int) {
+	s := Slots{Subject: -1, Verb: -1, Indirect: -1, Object: -1}
+	n := 0
+	for _, tok := range []interface{}{SlotSubject, "should", SlotVerbBase, "the", SlotObject, "with", SlotIndirect} {
+		if n >= len(words) {
+			return s, 0
+		}
+		slot, ok := tok.(Slot)
+		if !ok {
+			if words[n] != tok {
+				return s, 0
+			}
+			n++
+			continue
+		}
+		idx, m := v.Match(slot, words[n:])
+		if m == 0 {
+			return s, 0
+		}
+		switch slot {
+		case SlotSubject:
+			s.Subject = idx
+		case SlotVerbBase:
+			s.Verb = idx
+		case SlotObject:
+			s.Object = idx
+		case SlotIndirect:
+			s.Indirect = idx
+		}
+		n += m
+	}
+	return s, n
+}
+
+func TestCustomPatterns(t *testing.T) {
+	if err := RegisterPattern("polite", politePattern{}); err != nil {
+		t.Fatalf("RegisterPattern error: %v", err)
+	}
+	t.Cleanup(func() { unregisterPattern("polite") })
+	if _, ok := LookupPattern("polite"); !ok {
+		t.Fatal("LookupPattern did not find the registered pattern")
+	}
+
+	sender, _ := NewCipher("pattern-key")
+	receiver, _ := NewCipher("pattern-key")
+	for _, c := range []*Cipher{sender, receiver} {
+		if err := c.SetPatterns("works", "daily", "active", "polite"); err != nil {
+			t.Fatalf("SetPatterns error: %v", err)
+		}
+	}
+
+	input := []byte("Polite requests carry one extra bit per sentence")
+	text, err := sender.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if !strings.Contains(text, " should ") {
+		t.Errorf("custom pattern unused: %s", text)
+	}
+	decoded, err := receiver.Decode(text)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("Decode = %q, %v", decoded, err)
+	}
+
+	email, _ := sender.EncodeNatural(input)
+	decoded, err = receiver.DecodeNatural(email)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeNatural = %q, %v", decoded, err)
+	}
+
+	// The built-in patterns read the message differently
+	other, _ := NewCipher("pattern-key")
+	if decoded, err := other.Decode(text); err == nil && bytes.Equal(decoded, input) {
+		t.Error("default patterns decoded a custom-pattern message")
+	}
+}
+
+func TestSetPatternsValidation(t *testing.T) {
+	cipher, _ := NewCipher("pattern-key")
+	for _, names := range [][]string{
+		{"works", "daily", "active", "missing"},
+		{"works", "active", "for"},
+		{"works", "daily", "active", "for", "with"},
+		{"works", "daily", "daily", "active"},
+	} {
+		if err := cipher.SetPatterns(names...); err == nil {
+			t.Errorf("SetPatterns(%v) succeeded", names)
+		}
+	}
+
+	// Two full patterns that cannot be told apart
+	if err := RegisterPattern("active-again", mustTemplatePattern("S V I O")); err != nil {
+		t.Fatalf("RegisterPattern error: %v", err)
+	}
+	t.Cleanup(func() { unregisterPattern("active-again") })
+	if err := cipher.SetPatterns("works", "daily", "active", "active-again"); err == nil {
+		t.Error("ambiguous patterns were accepted")
+	}
+
+	// Patterns whose samples parse back but whose literals are verbs
+	for name, template := range map[string]string{"p1": "S V I O reviews", "p2": "S reports I O V"} {
+		if err := RegisterPattern(name, mustTemplatePattern(template)); err != nil {
+			t.Fatalf("RegisterPattern error: %v", err)
+		}
+		name := name
+		t.Cleanup(func() { unregisterPattern(name) })
+	}
+	if err := cipher.SetPatterns("works", "daily", "p1", "p2"); err == nil {
+		t.Error("patterns that can produce the same sentence were accepted")
+	}
+
+	for _, template := range []string{"S V O", "S V I O O", "S X", "S V I O Today"} {
+		if _, err := NewTemplatePattern(template); err == nil {
+			t.Errorf("NewTemplatePattern(%q) succeeded", template)
+		}
+	}
+}
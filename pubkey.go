@@ -0,0 +1,211 @@
+package sentencecipher
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Public-key mode (X25519 sealed boxes).
+//
+// The sender generates an ephemeral X25519 key pair for every message and derives
+// a ChaCha20-Poly1305 key from the shared secret with HKDF-SHA256. The encoded
+// bytes are:
+//
+//	ephemeral public key (32) || AEAD(compressed data)
+//
+// so the ephemeral key is carried by the first sentences of the message.
+const (
+	KeySize = 32
+
+	publicKeyPrefix  = "scpub1"
+	privateKeyPrefix = "scsec1"
+	sealedBoxInfo    = "sentence-cipher sealed box v1"
+)
+
+// PublicKey is an X25519 public key that senders encrypt to
+type PublicKey [KeySize]byte
+
+// PrivateKey is an X25519 private key used to open messages
+type PrivateKey [KeySize]byte
+
+// GenerateKeyPair creates a new random X25519 key pair
+func GenerateKeyPair() (*PublicKey, *PrivateKey, error) {
+	priv := new(PrivateKey)
+	if _, err := io.ReadFull(rand.Reader, priv[:]); err != nil {
+		return nil, nil, fmt.Errorf("key generation failed: %w", err)
+	}
+	pub, err := priv.Public()
+	if err != nil {
+		return nil, nil, err
+	}
+	return pub, priv, nil
+}
+
+// Public derives the public key for priv
+func (priv *PrivateKey) Public() (*PublicKey, error) {
+	p, err := curve25519.X25519(priv[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	pub := new(PublicKey)
+	copy(pub[:], p)
+	return pub, nil
+}
+
+// String returns the key as "scpub1" followed by unpadded base64url
+func (pub *PublicKey) String() string {
+	return publicKeyPrefix + encodeKey(pub[:])
+}
+
+// String returns the key as "scsec1" followed by unpadded base64url
+func (priv *PrivateKey) String() string {
+	return privateKeyPrefix + encodeKey(priv[:])
+}
+
+// ParsePublicKey parses the output of PublicKey.String
+func ParsePublicKey(s string) (*PublicKey, error) {
+	raw, err := parseKey(s, publicKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	pub := new(PublicKey)
+	copy(pub[:], raw)
+	return pub, nil
+}
+
+// ParsePrivateKey parses the output of PrivateKey.String
+func ParsePrivateKey(s string) (*PrivateKey, error) {
+	raw, err := parseKey(s, privateKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	priv := new(PrivateKey)
+	copy(priv[:], raw)
+	return priv, nil
+}
+
+func encodeKey(raw []byte) string {
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func parseKey(s, prefix string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, prefix) {
+		return nil, fmt.Errorf("key must start with %q", prefix)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, prefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	if len(raw) != KeySize {
+		return nil, fmt.Errorf("invalid key length %d", len(raw))
+	}
+	return raw, nil
+}
+
+// sealedBoxAEAD derives the per-message AEAD from the shared secret
+func sealedBoxAEAD(shared []byte, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	salt := sha256.Sum256(append(append([]byte{}, ephemeral...), recipient...))
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, salt[:], []byte(sealedBoxInfo)), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// seal encrypts data to pub (internal use)
+func seal(pub *PublicKey, data []byte) ([]byte, error) {
+	ephPub, ephPriv, err := GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(ephPriv[:], pub[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealedBoxAEAD(shared, ephPub[:], pub[:])
+	if err != nil {
+		return nil, err
+	}
+	// The key is unique per message, so a zero nonce is safe
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Seal(append([]byte{}, ephPub[:]...), nonce, data, nil), nil
+}
+
+// open decrypts a sealed box with priv (internal use)
+func open(priv *PrivateKey, box []byte) ([]byte, error) {
+	if len(box) < KeySize {
+		return nil, errors.New("sealed box too short")
+	}
+	pub, err := priv.Public()
+	if err != nil {
+		return nil, err
+	}
+	ephemeral := box[:KeySize]
+	shared, err := curve25519.X25519(priv[:], ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sealedBoxAEAD(shared, ephemeral, pub[:])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	data, err := aead.Open(nil, nonce, box[KeySize:], nil)
+	if err != nil {
+		return nil, errors.New("message was not sealed for this key or has been modified")
+	}
+	return data, nil
+}
+
+// EncodeFor compresses data, seals it to the recipient's public key and
+// converts the result to English sentences
+func (c *Cipher) EncodeFor(pub *PublicKey, data []byte) (string, error) {
+	compressed, err := compress(data)
+	if err != nil {
+		return "", fmt.Errorf("compression failed: %w", err)
+	}
+	box, err := seal(pub, compressed)
+	if err != nil {
+		return "", fmt.Errorf("sealing failed: %w", err)
+	}
+	return c.encodeRaw(box), nil
+}
+
+// DecodeWith converts English sentences back to a sealed box, opens it with
+// the recipient's private key and decompresses
+func (c *Cipher) DecodeWith(priv *PrivateKey, encoded string) ([]byte, error) {
+	box, err := c.decodeRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+	compressed, err := open(priv, box)
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed: %w", err)
+	}
+	return decompressed, nil
+}
+
+// EncodeFor seals data to a public key and encodes it (package-level)
+func EncodeFor(pub *PublicKey, data []byte) (string, error) {
+	return NewDefaultCipher().EncodeFor(pub, data)
+}
+
+// DecodeWith decodes and opens a message with a private key (package-level)
+func DecodeWith(priv *PrivateKey, encoded string) ([]byte, error) {
+	return NewDefaultCipher().DecodeWith(priv, encoded)
+}
@@ -0,0 +1,75 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeForDecodeWith(t *testing.T) {
+	pub, priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair error: %v", err)
+	}
+
+	input := []byte("Only the holder of the private key can read this.")
+	encoded, err := EncodeFor(pub, input)
+	if err != nil {
+		t.Fatalf("EncodeFor error: %v", err)
+	}
+	decoded, err := DecodeWith(priv, encoded)
+	if err != nil {
+		t.Fatalf("DecodeWith error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeWith mismatch\noriginal: %q\ndecoded:  %q", input, decoded)
+	}
+
+	// Each message uses a fresh ephemeral key
+	again, _ := EncodeFor(pub, input)
+	if again == encoded {
+		t.Error("two encodings of the same message should differ")
+	}
+}
+
+func TestDecodeWithWrongKey(t *testing.T) {
+	pub, _, _ := GenerateKeyPair()
+	_, otherPriv, _ := GenerateKeyPair()
+
+	encoded, err := EncodeFor(pub, []byte("secret"))
+	if err != nil {
+		t.Fatalf("EncodeFor error: %v", err)
+	}
+	if _, err := DecodeWith(otherPriv, encoded); err == nil {
+		t.Error("expected error when opening with the wrong private key")
+	}
+}
+
+func TestKeyStringRoundTrip(t *testing.T) {
+	pub, priv, _ := GenerateKeyPair()
+
+	parsedPub, err := ParsePublicKey(pub.String())
+	if err != nil || *parsedPub != *pub {
+		t.Errorf("ParsePublicKey round trip failed: %v", err)
+	}
+	parsedPriv, err := ParsePrivateKey(priv.String())
+	if err != nil || *parsedPriv != *priv {
+		t.Errorf("ParsePrivateKey round trip failed: %v", err)
+	}
+	if _, err := ParsePublicKey(priv.String()); err == nil {
+		t.Error("a private key should not parse as a public key")
+	}
+}
+
+func TestEncodeForWithKeyedCipher(t *testing.T) {
+	pub, priv, _ := GenerateKeyPair()
+	cipher, _ := NewCipher("word-list-key")
+
+	encoded, err := cipher.EncodeFor(pub, []byte("layered"))
+	if err != nil {
+		t.Fatalf("EncodeFor error: %v", err)
+	}
+	decoded, err := cipher.DecodeWith(priv, encoded)
+	if err != nil || string(decoded) != "layered" {
+		t.Errorf("DecodeWith = %q, %v", decoded, err)
+	}
+}
@@ -0,0 +1,155 @@
+package sentencecipher
+
+import (
+	"strings"
+)
+
+// Span is a run of encoded sentences found inside a larger document
+type Span struct {
+	Start, End int    // byte offsets of the span in the scanned text
+	Text       string // text[Start:End]
+	Sentences  int
+}
+
+// DecodedSpan is the result of decoding one Span
+type DecodedSpan struct {
+	Span
+	Data []byte
+	Err  error
+}
+
+// scanVocabularies returns the cipher itself followed by the cipher of every
+// registered theme: the word lists a sentence may be encoded with
+func (c *Cipher) scanVocabularies() []*Cipher {
+	vocabs := []*Cipher{c}
+	for _, name := range ThemeNames() {
+		vocabs = append(vocabs, c.themed(name))
+	}
+	return vocabs
+}
+
+// Scan finds the maximal runs of sentences that parse under the cipher's
+// grammar and word lists (or a registered theme's), ignoring everything
+// around them: greetings, signatures, quoted replies and ordinary prose.
+// All sentences of a span use the same vocabulary.
+func (c *Cipher) Scan(text string) []Span {
+	vocabs := c.scanVocabularies()
+
+	var spans []Span
+	var current *Span
+	var candidates []bool // vocabularies every sentence of current parses under
+
+	flush := func() {
+		if current != nil {
+			current.Text = text[current.Start:current.End]
+			spans = append(spans, *current)
+			current = nil
+		}
+	}
+
+	prev := 0
+	for prev < len(text) {
+		dot := strings.IndexAny(text[prev:], ".?")
+		if dot == -1 {
+			break
+		}
+		end := prev + dot + 1
+		segment := text[prev:end]
+
+		// The sentence is the whole segment, or failing that the part after
+		// its last line break (e.g. "Hi Team,\n\nTom loves Mary books.")
+		start, matches := prev, parsingVocabularies(vocabs, segment)
+		if matches == nil {
+			if nl := strings.LastIndexByte(segment, '\n'); nl != -1 {
+				if m := parsingVocabularies(vocabs, segment[nl+1:]); m != nil {
+					start, matches = prev+nl+1, m
+					flush()
+				}
+			}
+		}
+
+		if matches == nil {
+			flush()
+			prev = end
+			continue
+		}
+
+		if current != nil {
+			joint := intersect(candidates, matches)
+			if joint == nil {
+				flush()
+			} else {
+				candidates = joint
+			}
+		}
+		if current == nil {
+			start += len(segment[start-prev:]) - len(strings.TrimLeft(segment[start-prev:], " \t\r\n"))
+			current = &Span{Start: start}
+			candidates = matches
+		}
+		current.End = end
+		current.Sentences++
+		prev = end
+	}
+	flush()
+
+	return spans
+}
+
+// parsingVocabularies returns which vocabularies sentence parses under (nil for none)
+func parsingVocabularies(vocabs []*Cipher, sentence string) []bool {
+	if strings.TrimSpace(sentence) == "" {
+		return nil
+	}
+	words := naturalWords(normalizeText(sentence))
+	var matches []bool
+	for i, v := range vocabs {
+		if _, ok := v.parseSentence(words); ok {
+			if matches == nil {
+				matches = make([]bool, len(vocabs))
+			}
+			matches[i] = true
+		}
+	}
+	return matches
+}
+
+func intersect(a, b []bool) []bool {
+	var out []bool
+	for i := range a {
+		if a[i] && b[i] {
+			if out == nil {
+				out = make([]bool, len(a))
+			}
+			out[i] = true
+		}
+	}
+	return out
+}
+
+// DecodeAll scans text and decodes every span it finds, in plain mode when
+// possible and in natural mode otherwise
+func (c *Cipher) DecodeAll(text string) []DecodedSpan {
+	var results []DecodedSpan
+	for _, span := range c.Scan(text) {
+		result := DecodedSpan{Span: span}
+		result.Data, result.Err = c.Decode(span.Text)
+		if result.Err != nil {
+			if data, err := c.DecodeNatural(span.Text); err == nil {
+				result.Data, result.Err = data, nil
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Scan finds encoded spans using the default word lists (package-level)
+func Scan(text string) []Span {
+	return NewDefaultCipher().Scan(text)
+}
+
+// DecodeAll decodes every encoded span using the default word lists (package-level)
+func DecodeAll(text string) []DecodedSpan {
+	return NewDefaultCipher().DecodeAll(text)
+}
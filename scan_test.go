@@ -0,0 +1,66 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScanFindsEmbeddedSpans(t *testing.T) {
+	cipher, _ := NewCipher("scan-key")
+	first := []byte("The first hidden message")
+	second := []byte("And a second one, in natural mode")
+
+	plain, _ := cipher.Encode(first)
+	natural, _ := cipher.EncodeNatural(second)
+
+	thread := "Hey all,\n\nSorry for the delay. Here is what I got from the vendor:\n\n" +
+		plain + "\n\nLet me know what you think. Thanks.\n\n" +
+		"On Monday, Dana wrote:\n" + natural + "\n\n--\nSent from my phone."
+
+	spans := cipher.Scan(thread)
+	if len(spans) != 2 {
+		t.Fatalf("found %d spans, want 2: %+v", len(spans), spans)
+	}
+	if spans[0].Text != plain {
+		t.Errorf("first span = %q, want %q", spans[0].Text, plain)
+	}
+	if spans[0].Text != thread[spans[0].Start:spans[0].End] {
+		t.Error("span text does not match its offsets")
+	}
+	if !strings.Contains(natural, spans[1].Text) {
+		t.Errorf("second span %q is not part of the natural email", spans[1].Text)
+	}
+
+	results := cipher.DecodeAll(thread)
+	if len(results) != 2 {
+		t.Fatalf("DecodeAll returned %d results, want 2", len(results))
+	}
+	for i, want := range [][]byte{first, second} {
+		if results[i].Err != nil {
+			t.Fatalf("span %d: %v", i, results[i].Err)
+		}
+		if !bytes.Equal(results[i].Data, want) {
+			t.Errorf("span %d decoded %q, want %q", i, results[i].Data, want)
+		}
+	}
+}
+
+func TestScanIgnoresProse(t *testing.T) {
+	text := "Thanks for the update. We will review the numbers tomorrow. Best, Sam."
+	if spans := Scan(text); len(spans) != 0 {
+		t.Errorf("found spans in plain prose: %+v", spans)
+	}
+}
+
+func TestScanTechNatural(t *testing.T) {
+	cipher, _ := NewCipher("scan-key")
+	input := []byte("tech or business, the scanner finds it")
+	for i := 0; i < 6; i++ {
+		email, _ := cipher.EncodeNatural(input)
+		results := cipher.DecodeAll("FYI see below.\n\n" + email)
+		if len(results) != 1 || results[0].Err != nil || !bytes.Equal(results[0].Data, input) {
+			t.Fatalf("DecodeAll = %+v", results)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package sentencecipher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Session mode (hash ratchet).
+//
+// Both sides derive a sending and a receiving chain key from the shared key.
+// Every message advances the sender's chain one step:
+//
+//	message key = HMAC-SHA256(chain, 0x01)
+//	next chain  = HMAC-SHA256(chain, 0x02)
+//
+// and the old chain key is forgotten, so a stolen session state cannot decode
+// earlier messages. The encoded bytes are:
+//
+//	counter (4, big endian) || ChaCha20-Poly1305(message key, compressed data)
+//
+// with the counter as associated data. The words are shuffled by a header key
+// that is also derived from the shared key, so the counter is readable before
+// the message key is known.
+const (
+	// DefaultSessionWindow is how far ahead of the next expected counter a
+	// message may be, and how many skipped message keys are kept
+	DefaultSessionWindow = 256
+
+	sessionCounterSize = 4
+	sessionVersion     = 1
+)
+
+var (
+	// ErrSessionMessageReused is returned for a counter that was already decoded
+	// or fell out of the window
+	ErrSessionMessageReused = errors.New("session message already decoded or too old")
+	// ErrSessionTooFarAhead is returned for a counter beyond the window
+	ErrSessionTooFarAhead = errors.New("session message is too far ahead")
+)
+
+// Session encodes an ongoing conversation with a fresh key per message
+type Session struct {
+	// Window bounds skipped and out-of-order messages (DefaultSessionWindow by default)
+	Window int
+
+	headerKey string
+	header    *Cipher
+	sendChain []byte
+	sendN     uint32
+	recvChain []byte
+	recvN     uint32
+	skipped   map[uint32][]byte
+}
+
+// sessionState is the JSON form of a Session
+type sessionState struct {
+	Version   int               `json:"version"`
+	Window    int               `json:"window"`
+	HeaderKey string            `json:"header_key"`
+	SendChain []byte            `json:"send_chain"`
+	SendN     uint32            `json:"send_n"`
+	RecvChain []byte            `json:"recv_chain"`
+	RecvN     uint32            `json:"recv_n"`
+	Skipped   map[uint32][]byte `json:"skipped,omitempty"`
+}
+
+// NewSession starts a conversation between self and peer under a shared key.
+// The peer calls NewSession with the same key and the names swapped.
+func NewSession(key, self, peer string) (*Session, error) {
+	if key == "" {
+		return nil, errors.New("key is required")
+	}
+	if self == "" || peer == "" || self == peer {
+		return nil, errors.New("self and peer must be different non-empty names")
+	}
+
+	return newSession(DefaultSessionWindow,
+		hex.EncodeToString(sessionDerive(key, "header")),
+		sessionDerive(key, "chain:"+self+"->"+peer), 0,
+		sessionDerive(key, "chain:"+peer+"->"+self), 0,
+		make(map[uint32][]byte))
+}
+
+func newSession(window int, headerKey string, sendChain []byte, sendN uint32,
+	recvChain []byte, recvN uint32, skipped map[uint32][]byte) (*Session, error) {
+
+	header, err := NewCipher(headerKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		Window:    window,
+		headerKey: headerKey,
+		header:    header,
+		sendChain: sendChain,
+		sendN:     sendN,
+		recvChain: recvChain,
+		recvN:     recvN,
+		skipped:   skipped,
+	}, nil
+}
+
+func sessionDerive(key, label string) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("sentence-cipher/session/" + label))
+	return mac.Sum(nil)
+}
+
+// ratchetStep returns the message key for chain and the chain key after it
+func ratchetStep(chain []byte) (messageKey, next []byte) {
+	mac := hmac.New(sha256.New, chain)
+	mac.Write([]byte{0x01})
+	messageKey = mac.Sum(nil)
+	mac = hmac.New(sha256.New, chain)
+	mac.Write([]byte{0x02})
+	return messageKey, mac.Sum(nil)
+}
+
+// Sent returns the number of messages encoded so far
+func (s *Session) Sent() uint32 {
+	return s.sendN
+}
+
+// Received returns the next counter expected from the peer
+func (s *Session) Received() uint32 {
+	return s.recvN
+}
+
+// Encode compresses data, encrypts it under the next message key and
+// converts it to English sentences
+func (s *Session) Encode(data []byte) (string, error) {
+	raw, err := s.seal(data)
+	if err != nil {
+		return "", err
+	}
+	return s.header.encodeRaw(raw), nil
+}
+
+// EncodeNatural is Encode producing a natural email
+func (s *Session) EncodeNatural(data []byte) (string, error) {
+	raw, err := s.seal(data)
+	if err != nil {
+		return "", err
+	}
+	return s.header.encodeNaturalRaw(raw), nil
+}
+
+// Decode decodes a message from the peer. The session only advances when the
+// message authenticates.
+func (s *Session) Decode(encoded string) ([]byte, error) {
+	raw, err := s.header.decodeRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(raw)
+}
+
+// DecodeNatural is Decode for natural-mode emails
+func (s *Session) DecodeNatural(encoded string) ([]byte, error) {
+	raw, err := s.header.decodeNaturalRaw(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return s.open(raw)
+}
+
+func (s *Session) seal(data []byte) ([]byte, error) {
+	if s.sendN == math.MaxUint32 {
+		return nil, errors.New("session counter exhausted")
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+
+	messageKey, next := ratchetStep(s.sendChain)
+	aead, err := chacha20poly1305.New(messageKey[:chacha20poly1305.KeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	counter := make([]byte, sessionCounterSize)
+	binary.BigEndian.PutUint32(counter, s.sendN)
+	// Each message key is used once, so a zero nonce is safe
+	nonce := make([]byte, aead.NonceSize())
+	raw := aead.Seal(counter, nonce, compressed, counter)
+
+	s.sendChain = next
+	s.sendN++
+	return raw, nil
+}
+
+func (s *Session) open(raw []byte) ([]byte, error) {
+	if len(raw) < sessionCounterSize+chacha20poly1305.Overhead {
+		return nil, errors.New("session message too short")
+	}
+	counter := raw[:sessionCounterSize]
+	n := binary.BigEndian.Uint32(counter)
+	window := s.window()
+
+	// Work out the message key without touching the state yet
+	var messageKey, next []byte
+	newSkipped := make(map[uint32][]byte)
+	if n < s.recvN {
+		var ok bool
+		if messageKey, ok = s.skipped[n]; !ok {
+			return nil, ErrSessionMessageReused
+		}
+	} else {
+		if uint64(n-s.recvN) > uint64(window) {
+			return nil, ErrSessionTooFarAhead
+		}
+		chain := s.recvChain
+		for i := s.recvN; i < n; i++ {
+			newSkipped[i], chain = ratchetStep(chain)
+		}
+		messageKey, next = ratchetStep(chain)
+	}
+
+	aead, err := chacha20poly1305.New(messageKey[:chacha20poly1305.KeySize])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	compressed, err := aead.Open(nil, nonce, raw[sessionCounterSize:], counter)
+	if err != nil {
+		return nil, errors.New("session message did not authenticate")
+	}
+
+	if next == nil {
+		delete(s.skipped, n)
+	} else {
+		for i, k := range newSkipped {
+			s.skipped[i] = k
+		}
+		s.recvChain = next
+		s.recvN = n + 1
+	}
+	for i := range s.skipped {
+		if uint64(s.recvN-i) > uint64(window) {
+			delete(s.skipped, i)
+		}
+	}
+
+	decompressed, err := decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed: %w", err)
+	}
+	return decompressed, nil
+}
+
+func (s *Session) window() int {
+	if s.Window <= 0 {
+		return DefaultSessionWindow
+	}
+	return s.Window
+}
+
+// MarshalBinary serializes the session state. The result holds the current
+// chain keys, so store it as carefully as the shared key.
+func (s *Session) MarshalBinary() ([]byte, error) {
+	return json.Marshal(sessionState{
+		Version:   sessionVersion,
+		Window:    s.Window,
+		HeaderKey: s.headerKey,
+		SendChain: s.sendChain,
+		SendN:     s.sendN,
+		RecvChain: s.recvChain,
+		RecvN:     s.recvN,
+		Skipped:   s.skipped,
+	})
+}
+
+// UnmarshalSession restores a session serialized by MarshalBinary
+func UnmarshalSession(data []byte) (*Session, error) {
+	var state sessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid session state: %w", err)
+	}
+	if state.Version != sessionVersion {
+		return nil, fmt.Errorf("unsupported session state version %d", state.Version)
+	}
+	if len(state.SendChain) != sha256.Size || len(state.RecvChain) != sha256.Size {
+		return nil, errors.New("invalid session state: bad chain key")
+	}
+	if state.Skipped == nil {
+		state.Skipped = make(map[uint32][]byte)
+	}
+	return newSession(state.Window, state.HeaderKey, state.SendChain, state.SendN,
+		state.RecvChain, state.RecvN, state.Skipped)
+}
+
+// Save writes the session state to path (owner-only)
+func (s *Session) Save(path string) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadSession reads a session state file written by Save
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalSession(data)
+}
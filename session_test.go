@@ -0,0 +1,185 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newSessionPair(t *testing.T) (*Session, *Session) {
+	alice, err := NewSession("shared-key", "alice", "bob")
+	if err != nil {
+		t.Fatalf("NewSession error: %v", err)
+	}
+	bob, err := NewSession("shared-key", "bob", "alice")
+	if err != nil {
+		t.Fatalf("NewSession error: %v", err)
+	}
+	return alice, bob
+}
+
+func TestSessionConversation(t *testing.T) {
+	alice, bob := newSessionPair(t)
+
+	for i := 0; i < 3; i++ {
+		msg := []byte(fmt.Sprintf("alice to bob #%d", i))
+		text, err := alice.Encode(msg)
+		if err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+		decoded, err := bob.Decode(text)
+		if err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if !bytes.Equal(decoded, msg) {
+			t.Errorf("decoded %q, want %q", decoded, msg)
+		}
+
+		reply := []byte(fmt.Sprintf("bob to alice #%d", i))
+		text, err = bob.EncodeNatural(reply)
+		if err != nil {
+			t.Fatalf("EncodeNatural error: %v", err)
+		}
+		decoded, err = alice.DecodeNatural(text)
+		if err != nil {
+			t.Fatalf("DecodeNatural error: %v", err)
+		}
+		if !bytes.Equal(decoded, reply) {
+			t.Errorf("decoded %q, want %q", decoded, reply)
+		}
+	}
+
+	if alice.Sent() != 3 || bob.Received() != 3 {
+		t.Errorf("counters: sent %d, received %d", alice.Sent(), bob.Received())
+	}
+}
+
+func TestSessionFreshKeys(t *testing.T) {
+	alice, bob := newSessionPair(t)
+	msg := []byte("same words")
+	first, _ := alice.Encode(msg)
+	second, _ := alice.Encode(msg)
+	if first == second {
+		t.Error("identical messages should encode differently")
+	}
+
+	// The long-lived key alone does not decode session messages
+	plain, _ := NewCipher("shared-key")
+	if decoded, err := plain.Decode(first); err == nil && bytes.Equal(decoded, msg) {
+		t.Error("session message decoded with the shared key")
+	}
+
+	if _, err := bob.Decode(second); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if _, err := bob.Decode(first); err != nil {
+		t.Fatalf("out-of-order Decode error: %v", err)
+	}
+}
+
+func TestSessionOutOfOrder(t *testing.T) {
+	alice, bob := newSessionPair(t)
+
+	var texts []string
+	for i := 0; i < 5; i++ {
+		text, _ := alice.Encode([]byte(fmt.Sprintf("message %d", i)))
+		texts = append(texts, text)
+	}
+
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		decoded, err := bob.Decode(texts[i])
+		if err != nil {
+			t.Fatalf("Decode(%d) error: %v", i, err)
+		}
+		if want := fmt.Sprintf("message %d", i); string(decoded) != want {
+			t.Errorf("decoded %q, want %q", decoded, want)
+		}
+	}
+
+	if _, err := bob.Decode(texts[2]); !errors.Is(err, ErrSessionMessageReused) {
+		t.Errorf("replayed message: got %v, want ErrSessionMessageReused", err)
+	}
+}
+
+func TestSessionWindow(t *testing.T) {
+	alice, bob := newSessionPair(t)
+	bob.Window = 2
+
+	first, _ := alice.Encode([]byte("first"))
+	for i := 0; i < 3; i++ {
+		alice.Encode([]byte("lost"))
+	}
+	late, _ := alice.Encode([]byte("too far"))
+	if _, err := bob.Decode(late); !errors.Is(err, ErrSessionTooFarAhead) {
+		t.Fatalf("got %v, want ErrSessionTooFarAhead", err)
+	}
+
+	// A rejected message leaves the session untouched
+	if decoded, err := bob.Decode(first); err != nil || string(decoded) != "first" {
+		t.Fatalf("Decode after rejection = %q, %v", decoded, err)
+	}
+}
+
+func TestSessionTamperedMessage(t *testing.T) {
+	alice, bob := newSessionPair(t)
+	text, _ := alice.Encode([]byte("do not touch"))
+	other, _ := NewSession("other-key", "alice", "bob")
+	forged, _ := other.Encode([]byte("forged"))
+
+	if _, err := bob.Decode(forged); err == nil {
+		t.Error("expected error for message from another session")
+	}
+	if decoded, err := bob.Decode(text); err != nil || string(decoded) != "do not touch" {
+		t.Errorf("Decode = %q, %v", decoded, err)
+	}
+}
+
+func TestSessionSaveLoad(t *testing.T) {
+	alice, bob := newSessionPair(t)
+	dir, err := os.MkdirTemp("", "scsession")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bob.session")
+
+	skipped, _ := alice.Encode([]byte("skipped"))
+	text, _ := alice.Encode([]byte("hello"))
+	if _, err := bob.Decode(text); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if err := bob.Save(path); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	restored, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("LoadSession error: %v", err)
+	}
+	if restored.Received() != 2 {
+		t.Errorf("Received = %d, want 2", restored.Received())
+	}
+	if decoded, err := restored.Decode(skipped); err != nil || string(decoded) != "skipped" {
+		t.Errorf("Decode of skipped message after reload = %q, %v", decoded, err)
+	}
+	if _, err := restored.Decode(text); !errors.Is(err, ErrSessionMessageReused) {
+		t.Errorf("got %v, want ErrSessionMessageReused", err)
+	}
+
+	next, _ := alice.Encode([]byte("after reload"))
+	if decoded, err := restored.Decode(next); err != nil || string(decoded) != "after reload" {
+		t.Errorf("Decode after reload = %q, %v", decoded, err)
+	}
+}
+
+func TestNewSessionValidation(t *testing.T) {
+	if _, err := NewSession("", "a", "b"); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if _, err := NewSession("k", "a", "a"); err == nil {
+		t.Error("expected error for identical names")
+	}
+}
@@ -0,0 +1,226 @@
+package sentencecipher
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Shamir secret sharing over GF(256).
+//
+// Each byte of the secret is the constant term of a random polynomial of degree
+// threshold-1; share i holds the polynomial evaluated at x=i. Every share is
+// encoded through EncodeNatural with a 6-byte header in its first sentences:
+// - Bytes 0-3: share-set ID (random, shared by all shares of one secret)
+// - Byte 4:    share index x (1-255)
+// - Byte 5:    threshold k
+const shareHeaderSize = 6
+
+// ErrShareMismatch is returned when shares do not belong to the same secret
+var ErrShareMismatch = errors.New("shares belong to different secrets")
+
+// InsufficientSharesError is returned by Combine when fewer than threshold shares are given
+type InsufficientSharesError struct {
+	Have int
+	Need int
+}
+
+func (e *InsufficientSharesError) Error() string {
+	return fmt.Sprintf("not enough shares: have %d, need %d", e.Have, e.Need)
+}
+
+// GF(256) log/exp tables using the AES polynomial x^8 + x^4 + x^3 + x + 1 (0x11b)
+var gfExp [510]byte
+var gfLog [256]byte
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+		// multiply by generator 3: x*2 ^ x
+		hi := x & 0x80
+		x2 := x << 1
+		if hi != 0 {
+			x2 ^= 0x1b
+		}
+		x = x2 ^ x
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if b == 0 {
+		panic("sentencecipher: division by zero in GF(256)")
+	}
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// ShareSecret splits secret into n natural-mode emails such that any
+// threshold of them reconstruct it with Combine
+func (c *Cipher) ShareSecret(secret []byte, n, threshold int) ([]string, error) {
+	if threshold < 2 {
+		return nil, errors.New("threshold must be at least 2")
+	}
+	if n < threshold {
+		return nil, errors.New("number of shares must be at least the threshold")
+	}
+	if n > 255 {
+		return nil, errors.New("at most 255 shares are supported")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+
+	header := make([]byte, 4, shareHeaderSize)
+	if _, err := rand.Read(header); err != nil {
+		return nil, fmt.Errorf("share-set ID generation failed: %w", err)
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = append(append([]byte{}, header...), byte(i+1), byte(threshold))
+	}
+
+	// coeffs[0] is the secret byte, the rest are random
+	coeffs := make([]byte, threshold)
+	for _, b := range secret {
+		coeffs[0] = b
+		if _, err := rand.Read(coeffs[1:]); err != nil {
+			return nil, fmt.Errorf("coefficient generation failed: %w", err)
+		}
+		for i := range shares {
+			shares[i] = append(shares[i], evalPolynomial(coeffs, byte(i+1)))
+		}
+	}
+
+	encoded := make([]string, n)
+	for i, share := range shares {
+		text, err := c.EncodeNatural(share)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = text
+	}
+	return encoded, nil
+}
+
+// evalPolynomial evaluates coeffs at x using Horner's method
+func evalPolynomial(coeffs []byte, x byte) byte {
+	var y byte
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ coeffs[i]
+	}
+	return y
+}
+
+// Combine decodes natural-mode shares and reconstructs the secret. Shares must
+// come from the same ShareSecret call; at least threshold distinct shares are
+// needed, and any beyond that must agree with the secret they reconstruct.
+func (c *Cipher) Combine(shares ...string) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+
+	var setID []byte
+	threshold := 0
+	points := make(map[byte][]byte)
+
+	for _, text := range shares {
+		share, err := c.DecodeNatural(text)
+		if err != nil {
+			return nil, err
+		}
+		if len(share) <= shareHeaderSize {
+			return nil, errors.New("share too short to carry a header")
+		}
+
+		x, k := share[4], int(share[5])
+		if x == 0 || k < 2 {
+			return nil, fmt.Errorf("invalid share header: index %d, threshold %d", x, k)
+		}
+
+		if setID == nil {
+			setID = share[:4]
+			threshold = k
+		} else if string(setID) != string(share[:4]) || k != threshold {
+			return nil, ErrShareMismatch
+		}
+
+		y := share[shareHeaderSize:]
+		if prev, ok := points[x]; ok {
+			if string(prev) != string(y) {
+				return nil, fmt.Errorf("conflicting shares for index %d", x)
+			}
+			continue
+		}
+		for _, other := range points {
+			if len(other) != len(y) {
+				return nil, ErrShareMismatch
+			}
+			break
+		}
+		points[x] = y
+	}
+
+	if len(points) < threshold {
+		return nil, &InsufficientSharesError{Have: len(points), Need: threshold}
+	}
+
+	// Interpolate from the lowest threshold indices, then check every extra
+	// share against the recovered polynomials
+	xs := make([]byte, 0, len(points))
+	for x := range points {
+		xs = append(xs, x)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+	basisXs, extraXs := xs[:threshold], xs[threshold:]
+	for _, x := range extraXs {
+		if string(interpolate(basisXs, points, x)) != string(points[x]) {
+			return nil, fmt.Errorf("share %d does not match the others: %w", x, ErrShareMismatch)
+		}
+	}
+	return interpolate(basisXs, points, 0), nil
+}
+
+// interpolate evaluates at x the polynomials through the points at xs (Lagrange)
+func interpolate(xs []byte, points map[byte][]byte, x byte) []byte {
+	out := make([]byte, len(points[xs[0]]))
+	for i, xi := range xs {
+		// basis = prod_{j!=i} (x ^ xj) / (xi ^ xj)  (subtraction is XOR in GF(256))
+		basis := byte(1)
+		for j, xj := range xs {
+			if i == j {
+				continue
+			}
+			basis = gfMul(basis, gfDiv(x^xj, xi^xj))
+		}
+		for pos, y := range points[xi] {
+			out[pos] ^= gfMul(y, basis)
+		}
+	}
+	return out
+}
+
+// ShareSecret splits a secret into natural-mode shares (package-level)
+func ShareSecret(secret []byte, n, threshold int) ([]string, error) {
+	return NewDefaultCipher().ShareSecret(secret, n, threshold)
+}
+
+// Combine reconstructs a secret from natural-mode shares (package-level)
+func Combine(shares ...string) ([]byte, error) {
+	return NewDefaultCipher().Combine(shares...)
+}
@@ -0,0 +1,93 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestGF256Arithmetic(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			p := gfMul(byte(a), byte(b))
+			if gfDiv(p, byte(b)) != byte(a) {
+				t.Fatalf("gfDiv(gfMul(%d, %d), %d) != %d", a, b, b, a)
+			}
+		}
+	}
+	// Known AES field product: 0x57 * 0x83 = 0xc1
+	if got := gfMul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("gfMul(0x57, 0x83) = %#x, want 0xc1", got)
+	}
+}
+
+func TestShareSecretCombine(t *testing.T) {
+	secret := []byte("launch codes: 0000")
+	cipher, _ := NewCipher("share-key")
+
+	shares, err := cipher.ShareSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("ShareSecret error: %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("expected 5 shares, got %d", len(shares))
+	}
+
+	subsets := [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}}
+	for _, subset := range subsets {
+		var picked []string
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		got, err := cipher.Combine(picked...)
+		if err != nil {
+			t.Fatalf("Combine(%v) error: %v", subset, err)
+		}
+		if !bytes.Equal(got, secret) {
+			t.Errorf("Combine(%v) = %q, want %q", subset, got, secret)
+		}
+	}
+}
+
+func TestCombineTooFewShares(t *testing.T) {
+	shares, err := ShareSecret([]byte("top secret"), 4, 3)
+	if err != nil {
+		t.Fatalf("ShareSecret error: %v", err)
+	}
+
+	// A repeated share does not count twice
+	_, err = Combine(shares[0], shares[1], shares[1])
+	var insufficient *InsufficientSharesError
+	if !errors.As(err, &insufficient) {
+		t.Fatalf("expected InsufficientSharesError, got %v", err)
+	}
+	if insufficient.Have != 2 || insufficient.Need != 3 {
+		t.Errorf("got have=%d need=%d, want have=2 need=3", insufficient.Have, insufficient.Need)
+	}
+}
+
+func TestCombineMixedSets(t *testing.T) {
+	first, _ := ShareSecret([]byte("first secret"), 3, 2)
+	second, _ := ShareSecret([]byte("other secret"), 3, 2)
+
+	if _, err := Combine(first[0], second[1]); !errors.Is(err, ErrShareMismatch) {
+		t.Errorf("expected ErrShareMismatch, got %v", err)
+	}
+}
+
+func TestCombineChecksExtraShares(t *testing.T) {
+	cipher, _ := NewCipher("share-key")
+	shares, _ := cipher.ShareSecret([]byte("top secret"), 4, 2)
+
+	// Same set and index, one corrupted byte
+	raw, _ := cipher.DecodeNatural(shares[3])
+	raw[len(raw)-1] ^= 0x01
+	corrupted, _ := cipher.EncodeNatural(raw)
+
+	if _, err := cipher.Combine(shares[0], shares[1], corrupted); !errors.Is(err, ErrShareMismatch) {
+		t.Errorf("expected ErrShareMismatch for a corrupted extra share, got %v", err)
+	}
+	if got, err := cipher.Combine(shares[0], shares[1], shares[3]); err != nil || string(got) != "top secret" {
+		t.Errorf("Combine = %q, %v", got, err)
+	}
+}
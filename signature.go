@@ -0,0 +1,127 @@
+package sentencecipher
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Ed25519 signatures.
+//
+// Sign appends a postscript paragraph whose sentences encode the 64-byte
+// signature with the default word lists:
+//
+//	...
+//	Best regards,
+//	Samantha
+//
+//	P.S. Vincent requests frank refunds. Lucy furnishes victor papers. ...
+//
+// The signature covers the text with whitespace collapsed, so reflowing the
+// message does not invalidate it.
+const (
+	signingKeyPrefix   = "scsk1"
+	verifyingKeyPrefix = "scvk1"
+	signatureMarker    = "P.S."
+	signatureSeparator = "\n\n" + signatureMarker + " "
+)
+
+var (
+	// ErrNoSignature is returned by Verify when the text carries no signature block
+	ErrNoSignature = errors.New("message is not signed")
+	// ErrBadSignature is returned by Verify when the signature does not match
+	ErrBadSignature = errors.New("signature verification failed")
+)
+
+// SigningKey is an Ed25519 private key (stored as its 32-byte seed)
+type SigningKey [ed25519.SeedSize]byte
+
+// VerifyingKey is an Ed25519 public key
+type VerifyingKey [ed25519.PublicKeySize]byte
+
+// GenerateSigningKey creates a new random Ed25519 key pair
+func GenerateSigningKey() (*VerifyingKey, *SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("key generation failed: %w", err)
+	}
+	sk := new(SigningKey)
+	copy(sk[:], priv.Seed())
+	return sk.Public(), sk, nil
+}
+
+// Public derives the verifying key for sk
+func (sk *SigningKey) Public() *VerifyingKey {
+	vk := new(VerifyingKey)
+	copy(vk[:], ed25519.NewKeyFromSeed(sk[:]).Public().(ed25519.PublicKey))
+	return vk
+}
+
+// String returns the key as "scsk1" followed by unpadded base64url
+func (sk *SigningKey) String() string {
+	return signingKeyPrefix + encodeKey(sk[:])
+}
+
+// String returns the key as "scvk1" followed by unpadded base64url
+func (vk *VerifyingKey) String() string {
+	return verifyingKeyPrefix + encodeKey(vk[:])
+}
+
+// ParseSigningKey parses the output of SigningKey.String
+func ParseSigningKey(s string) (*SigningKey, error) {
+	raw, err := parseKey(s, signingKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	sk := new(SigningKey)
+	copy(sk[:], raw)
+	return sk, nil
+}
+
+// ParseVerifyingKey parses the output of VerifyingKey.String
+func ParseVerifyingKey(s string) (*VerifyingKey, error) {
+	raw, err := parseKey(s, verifyingKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	vk := new(VerifyingKey)
+	copy(vk[:], raw)
+	return vk, nil
+}
+
+// canonicalText collapses whitespace so reflowed text verifies
+func canonicalText(text string) []byte {
+	return []byte(strings.Join(strings.Fields(text), " "))
+}
+
+// Sign appends a block of signature sentences to an encoded message
+func Sign(sk *SigningKey, text string) (string, error) {
+	text = strings.TrimRight(text, " \t\r\n")
+	if text == "" {
+		return "", errors.New("nothing to sign")
+	}
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(sk[:]), canonicalText(text))
+	return text + signatureSeparator + capitalize(NewDefaultCipher().encodeRaw(sig)), nil
+}
+
+// Verify checks the signature block appended by Sign and returns the message
+// without it, ready for decoding
+func Verify(vk *VerifyingKey, text string) (string, error) {
+	// Locate the marker alone so reflowed line breaks around it do not matter
+	idx := strings.LastIndex(text, signatureMarker)
+	if idx == -1 {
+		return "", ErrNoSignature
+	}
+	message := strings.TrimRight(text[:idx], " \t\r\n")
+
+	sig, err := NewDefaultCipher().decodeRaw(strings.TrimSpace(text[idx+len(signatureMarker):]))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return "", ErrBadSignature
+	}
+	if !ed25519.Verify(ed25519.PublicKey(vk[:]), canonicalText(message), sig) {
+		return "", ErrBadSignature
+	}
+	return message, nil
+}
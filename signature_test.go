@@ -0,0 +1,80 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	vk, sk, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("GenerateSigningKey error: %v", err)
+	}
+
+	input := []byte("Signed and sealed")
+	encoded, err := EncodeNatural(input)
+	if err != nil {
+		t.Fatalf("EncodeNatural error: %v", err)
+	}
+	signed, err := Sign(sk, encoded)
+	if err != nil {
+		t.Fatalf("Sign error: %v", err)
+	}
+	if !strings.Contains(signed, "\n\nP.S. ") {
+		t.Errorf("signature block should be a postscript:\n%s", signed)
+	}
+
+	message, err := Verify(vk, signed)
+	if err != nil {
+		t.Fatalf("Verify error: %v", err)
+	}
+	decoded, err := DecodeNatural(message)
+	if err != nil {
+		t.Fatalf("DecodeNatural error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Errorf("decoded %q, want %q", decoded, input)
+	}
+
+	// Reflowed whitespace still verifies
+	if _, err := Verify(vk, strings.ReplaceAll(signed, ". ", ".\r\n")); err != nil {
+		t.Errorf("Verify after reflow error: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	vk, sk, _ := GenerateSigningKey()
+	otherVK, _, _ := GenerateSigningKey()
+
+	encoded, _ := Encode([]byte("pay 100 to alice"))
+	signed, _ := Sign(sk, encoded)
+
+	if _, err := Verify(otherVK, signed); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong key: expected ErrBadSignature, got %v", err)
+	}
+
+	words := strings.Fields(signed)
+	words[0] = "zoe"
+	if _, err := Verify(vk, strings.Join(words, " ")); err == nil {
+		t.Error("tampered message should not verify")
+	}
+
+	if _, err := Verify(vk, encoded); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("unsigned: expected ErrNoSignature, got %v", err)
+	}
+}
+
+func TestSigningKeyStringRoundTrip(t *testing.T) {
+	vk, sk, _ := GenerateSigningKey()
+
+	parsedSK, err := ParseSigningKey(sk.String())
+	if err != nil || *parsedSK != *sk {
+		t.Errorf("ParseSigningKey round trip failed: %v", err)
+	}
+	parsedVK, err := ParseVerifyingKey(vk.String())
+	if err != nil || *parsedVK != *vk {
+		t.Errorf("ParseVerifyingKey round trip failed: %v", err)
+	}
+}
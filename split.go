@@ -0,0 +1,210 @@
+package sentencecipher
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Every split message starts with an 8-byte header carried in its data sentences:
+// - Bytes 0-3: message-set ID (random, shared by all messages of one Split)
+// - Bytes 4-5: sequence number (1-based, big endian)
+// - Bytes 6-7: total number of messages (big endian)
+const splitHeaderSize = 8
+
+// ErrMessageSetMismatch is returned when a message belongs to a different Split
+var ErrMessageSetMismatch = errors.New("message belongs to a different message set")
+
+// Split compresses data and spreads it over a thread of natural-mode emails,
+// each holding at most maxSentences sentences. Subjects carry an
+// "(n/total)" counter, e.g. "Project Update (3/12)". Padding and chaff would
+// add sentences Split cannot count in advance, so a cipher with either fails.
+func (c *Cipher) Split(data []byte, maxSentences int) ([]string, error) {
+	if c.padding != nil || c.chaffRatio > 0 {
+		return nil, errors.New("split messages cannot be padded or carry chaff")
+	}
+	// n bytes take at most ceil(n/3) sentences (the byte layout), after the
+	// nonce sentences of randomized encoding
+	if c.randomized {
+		maxSentences -= nonceSentenceCount
+	}
+	perMessage := maxSentences*3 - splitHeaderSize
+	if perMessage <= 0 {
+		least := splitHeaderSize/3 + 1
+		if c.randomized {
+			least += nonceSentenceCount
+		}
+		return nil, fmt.Errorf("maxSentences must be at least %d", least)
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+	var chunks [][]byte
+	for start := 0; start < len(compressed); start += perMessage {
+		end := start + perMessage
+		if end > len(compressed) {
+			end = len(compressed)
+		}
+		chunks = append(chunks, compressed[start:end])
+	}
+	return c.splitRaw(chunks)
+}
+
+// SplitN compresses data and spreads it evenly over exactly n natural-mode
+// emails; the first len%n carry one byte more, and payloads shorter than n
+// leave the last messages with a header only
+func (c *Cipher) SplitN(data []byte, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, errors.New("number of messages must be positive")
+	}
+	compressed, err := compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("compression failed: %w", err)
+	}
+	chunks := make([][]byte, n)
+	start := 0
+	for i := range chunks {
+		size := len(compressed) / n
+		if i < len(compressed)%n {
+			size++
+		}
+		chunks[i] = compressed[start : start+size]
+		start += size
+	}
+	return c.splitRaw(chunks)
+}
+
+// splitRaw turns chunks of already-compressed data into headed emails (internal use)
+func (c *Cipher) splitRaw(chunks [][]byte) ([]string, error) {
+	total := len(chunks)
+	if total > 0xFFFF {
+		return nil, fmt.Errorf("payload needs %d messages, at most %d are supported", total, 0xFFFF)
+	}
+
+	setID := make([]byte, 4)
+	if _, err := rand.Read(setID); err != nil {
+		return nil, fmt.Errorf("message-set ID generation failed: %w", err)
+	}
+
+	messages := make([]string, 0, total)
+	for i, data := range chunks {
+		seq := i + 1
+		chunk := make([]byte, splitHeaderSize, splitHeaderSize+len(data))
+		copy(chunk, setID)
+		binary.BigEndian.PutUint16(chunk[4:6], uint16(seq))
+		binary.BigEndian.PutUint16(chunk[6:8], uint16(total))
+		chunk = append(chunk, data...)
+
+		suffix := fmt.Sprintf(" (%d/%d)", seq, total)
+		messages = append(messages, c.encodeNaturalEmail(chunk, suffix))
+	}
+	return messages, nil
+}
+
+// Joiner collects split messages as they arrive, in any order, and
+// rebuilds the original data once every message is present
+type Joiner struct {
+	cipher *Cipher
+	setID  uint32
+	total  int
+	chunks map[int][]byte
+}
+
+// NewJoiner creates a Joiner that decodes messages with this cipher
+func (c *Cipher) NewJoiner() *Joiner {
+	return &Joiner{
+		cipher: c,
+		chunks: make(map[int][]byte),
+	}
+}
+
+// Add decodes one message. Repeated messages are ignored. It reports
+// whether the set is complete after adding the message.
+func (j *Joiner) Add(message string) (bool, error) {
+	raw, err := j.cipher.decodeNaturalRaw(message)
+	if err != nil {
+		return false, err
+	}
+	if len(raw) < splitHeaderSize {
+		return false, errors.New("message too short to carry a split header")
+	}
+
+	setID := binary.BigEndian.Uint32(raw[0:4])
+	seq := int(binary.BigEndian.Uint16(raw[4:6]))
+	total := int(binary.BigEndian.Uint16(raw[6:8]))
+	if total == 0 || seq == 0 || seq > total {
+		return false, fmt.Errorf("invalid sequence number %d/%d", seq, total)
+	}
+
+	if j.total == 0 {
+		j.setID = setID
+		j.total = total
+	} else if setID != j.setID || total != j.total {
+		return false, ErrMessageSetMismatch
+	}
+
+	if _, ok := j.chunks[seq]; !ok {
+		j.chunks[seq] = raw[splitHeaderSize:]
+	}
+	return j.Done(), nil
+}
+
+// Progress returns how many distinct messages have been received and how many
+// are expected (0 until the first message arrives)
+func (j *Joiner) Progress() (received, total int) {
+	return len(j.chunks), j.total
+}
+
+// Done reports whether every message of the set has been received
+func (j *Joiner) Done() bool {
+	return j.total > 0 && len(j.chunks) == j.total
+}
+
+// Missing returns the 1-based sequence numbers still outstanding
+func (j *Joiner) Missing() []int {
+	var missing []int
+	for seq := 1; seq <= j.total; seq++ {
+		if _, ok := j.chunks[seq]; !ok {
+			missing = append(missing, seq)
+		}
+	}
+	return missing
+}
+
+// Bytes joins and decompresses the original data. It returns a
+// *MissingPartsError if the set is still incomplete.
+func (j *Joiner) Bytes() ([]byte, error) {
+	if j.total == 0 {
+		return nil, errors.New("no messages received")
+	}
+	if missing := j.Missing(); len(missing) > 0 {
+		return nil, &MissingPartsError{Missing: missing, Total: j.total}
+	}
+
+	var compressed []byte
+	for seq := 1; seq <= j.total; seq++ {
+		compressed = append(compressed, j.chunks[seq]...)
+	}
+	decompressed, err := decompress(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("decompression failed: %w", err)
+	}
+	return decompressed, nil
+}
+
+// Split compresses then spreads data over natural-mode emails (package-level)
+func Split(data []byte, maxSentences int) ([]string, error) {
+	return NewDefaultCipher().Split(data, maxSentences)
+}
+
+// SplitN compresses then spreads data over n natural-mode emails (package-level)
+func SplitN(data []byte, n int) ([]string, error) {
+	return NewDefaultCipher().SplitN(data, n)
+}
+
+// NewJoiner creates a Joiner using the default cipher (package-level)
+func NewJoiner() *Joiner {
+	return NewDefaultCipher().NewJoiner()
+}
@@ -0,0 +1,154 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func splitTestData() []byte {
+	data := make([]byte, 600)
+	for i := range data {
+		data[i] = byte(i*197 + i*i*13)
+	}
+	return data
+}
+
+func TestSplitJoin(t *testing.T) {
+	input := splitTestData()
+	cipher, _ := NewCipher("thread-key")
+
+	messages, err := cipher.Split(input, 20)
+	if err != nil {
+		t.Fatalf("Split error: %v", err)
+	}
+	if len(messages) < 3 {
+		t.Fatalf("expected several messages, got %d", len(messages))
+	}
+	if !strings.Contains(messages[0], "(1/") {
+		t.Errorf("subject should carry a counter:\n%s", messages[0])
+	}
+
+	joiner := cipher.NewJoiner()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if _, err := joiner.Add(messages[i]); err != nil {
+			t.Fatalf("Add(%d) error: %v", i+1, err)
+		}
+		// Repeats are ignored
+		if _, err := joiner.Add(messages[i]); err != nil {
+			t.Fatalf("Add duplicate error: %v", err)
+		}
+		received, total := joiner.Progress()
+		if received != len(messages)-i || total != len(messages) {
+			t.Errorf("Progress = %d/%d, want %d/%d", received, total, len(messages)-i, len(messages))
+		}
+	}
+
+	if !joiner.Done() {
+		t.Fatal("joiner should be done")
+	}
+	decoded, err := joiner.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes error: %v", err)
+	}
+	if !bytes.Equal(decoded, input) {
+		t.Error("Split/Join mismatch")
+	}
+}
+
+func TestSplitNJoinIncomplete(t *testing.T) {
+	messages, err := SplitN(splitTestData(), 4)
+	if err != nil {
+		t.Fatalf("SplitN error: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(messages))
+	}
+
+	joiner := NewJoiner()
+	for _, i := range []int{0, 3} {
+		if done, err := joiner.Add(messages[i]); err != nil || done {
+			t.Fatalf("Add(%d) = %v, %v", i+1, done, err)
+		}
+	}
+
+	_, err = joiner.Bytes()
+	var missingErr *MissingPartsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expected MissingPartsError, got %v", err)
+	}
+	if len(missingErr.Missing) != 2 || missingErr.Missing[0] != 2 || missingErr.Missing[1] != 3 {
+		t.Errorf("Missing = %v, want [2 3]", missingErr.Missing)
+	}
+}
+
+func TestJoinerRejectsOtherSet(t *testing.T) {
+	first, _ := SplitN([]byte("first message set"), 2)
+	second, _ := SplitN([]byte("second message set"), 2)
+
+	joiner := NewJoiner()
+	if _, err := joiner.Add(first[0]); err != nil {
+		t.Fatalf("Add error: %v", err)
+	}
+	if _, err := joiner.Add(second[1]); !errors.Is(err, ErrMessageSetMismatch) {
+		t.Errorf("expected ErrMessageSetMismatch, got %v", err)
+	}
+}
+
+func TestSplitNReturnsExactlyN(t *testing.T) {
+	cipher, _ := NewCipher("thread-key")
+	for _, input := range [][]byte{[]byte("hi"), splitTestData()} {
+		compressed, _ := compress(input)
+		for _, n := range []int{4, len(compressed) + 3} {
+			messages, err := cipher.SplitN(input, n)
+			if err != nil {
+				t.Fatalf("SplitN error: %v", err)
+			}
+			if len(messages) != n {
+				t.Fatalf("%d compressed bytes over %d messages: got %d", len(compressed), n, len(messages))
+			}
+			joiner := cipher.NewJoiner()
+			for _, msg := range messages {
+				if _, err := joiner.Add(msg); err != nil {
+					t.Fatalf("Add error: %v", err)
+				}
+			}
+			if decoded, err := joiner.Bytes(); err != nil || !bytes.Equal(decoded, input) {
+				t.Errorf("Bytes = %v, %v", len(decoded), err)
+			}
+		}
+	}
+}
+
+func TestSplitRespectsMaxSentences(t *testing.T) {
+	cipher, _ := NewCipher("thread-key")
+	cipher.SetRandomized(true)
+	messages, err := cipher.Split(splitTestData(), 12)
+	if err != nil {
+		t.Fatalf("Split error: %v", err)
+	}
+	for i, msg := range messages {
+		// Subject, blank line and opener before the body; closer and sender after it
+		lines := strings.Split(msg, "\n")
+		n := 0
+		for _, sentence := range splitSentences(strings.Join(lines[3:len(lines)-2], " ")) {
+			if len(naturalWords(sentence)) > 0 {
+				n++
+			}
+		}
+		if n > 12 {
+			t.Errorf("message %d has %d sentences, want at most 12", i+1, n)
+		}
+	}
+
+	padded, _ := NewCipher("thread-key")
+	padded.SetPadding(PowerOfTwoPadding{})
+	chaffed, _ := NewCipher("thread-key")
+	chaffed.SetChaffRatio(0.5)
+	for _, c := range []*Cipher{padded, chaffed} {
+		if _, err := c.Split(splitTestData(), 12); err == nil {
+			t.Error("Split succeeded with padding or chaff set")
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package sentencecipher
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Spoken messages are read aloud and come back from speech-to-text as a
+// stream of words, usually without punctuation. Every sentence pattern has a
+// recognizable shape, so the stream can be segmented with the word lists
+// alone: a name, then a work ending or a verb, then a daily ending or a name
+// and an object (see fillers.go).
+
+// spokenReplacements swaps words a speech recognizer cannot spell reliably
+// (homophones of another list word or of a common word, letters and digits)
+// for words it can. SetSpokenVocabulary applies them.
+var spokenReplacements = map[string]string{
+	// Names
+	"shawn": "quentin", "stephen": "gordon", "katherine": "beatrice", "sara": "valerie",
+	"ann": "lorraine", "jean": "marcus",
+	// Business verbs and objects
+	"meets": "visits", "writes": "authors", "mails": "dispatches", "sites": "venues",
+	"prints": "printouts",
+	// Tech verbs and objects
+	"pairs": "couples", "caches": "memoizes", "rows": "tuples", "bytes": "octets",
+	"c": "fortran", "cpp": "pascal", "csharp": "delphi", "s3": "buckets", "ec2": "instances",
+	"mic": "headset", "vue": "svelte", "perl": "cobol",
+}
+
+// spokenVariants maps other spellings a recognizer may produce to the list word
+var spokenVariants = map[string]string{
+	"jon": "john", "karl": "carl", "tracey": "tracy", "lin": "lynn", "clare": "claire",
+	"phillip": "philip", "bryan": "brian", "cate": "kate", "allen": "alan", "allan": "alan",
+	"alison": "allison", "nicolas": "nicholas", "theresa": "teresa", "geoffrey": "jeffrey",
+	"zackary": "zachary", "laurence": "lawrence", "frances": "francis", "erik": "eric",
+	"abbey": "abby", "bret": "brett", "brook": "brooke", "kasey": "casey", "sheryl": "cheryl",
+	"shaun": "sean", "anne": "ann", "gene": "jean", "sarah": "sara",
+	"shawn": "sean", "stephen": "steven", "kathryn": "catherine", "katherine": "catherine",
+	"cheques": "checks", "draughts": "drafts", "acknowledgements": "acknowledgments",
+	"wi-fi": "wifi",
+}
+
+// SetSpokenVocabulary makes the cipher use word lists a speech recognizer
+// transcribes unambiguously (see DecodeTranscript). Both sides must agree on it.
+func (c *Cipher) SetSpokenVocabulary(on bool) {
+	if c.spoken == on {
+		return
+	}
+	c.spoken = on
+	for _, list := range [][]string{c.names, c.verbs, c.objects} {
+		replaceWords(list, on)
+	}
+}
+
+// replaceWords applies (or, with on false, reverts) spokenReplacements in place,
+// skipping replacements that would duplicate a word already in list
+func replaceWords(list []string, on bool) {
+	for from, to := range spokenReplacements {
+		if !on {
+			from, to = to, from
+		}
+		if i := findIndex(list, from); i != -1 && findIndex(list, to) == -1 {
+			list[i] = to
+		}
+	}
+}
+
+// transcriptWords splits a transcript into lowercase words, dropping punctuation
+func transcriptWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(normalizeText(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+}
+
+// segmentTranscript rebuilds the sentences of a punctuation-free word stream
+// using v's word lists. Words that start no sentence (greetings, connectors,
+// small talk) are skipped.
+func segmentTranscript(words []string, v *Cipher) []string {
+	var sentences []string
+	for i := 0; i < len(words); {
+		p, n := v.matchSentence(words[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		sentences = append(sentences, p.render(v))
+		i += n
+	}
+	return sentences
+}
+
+// DecodeTranscript decodes a plain-mode message from a speech-to-text
+// transcript, with or without punctuation
+func (c *Cipher) DecodeTranscript(transcript string) ([]byte, error) {
+	sentences := segmentTranscript(transcriptWords(transcript), c)
+	if len(sentences) == 0 {
+		return nil, errors.New("no sentences found in transcript")
+	}
+	return c.Decode(strings.Join(sentences, " "))
+}
+
+// DecodeNaturalTranscript decodes a natural-mode message from a speech-to-text
+// transcript. The body is segmented with the theme whose vocabulary covers
+// most of it; greetings, connectors and sign-offs are skipped.
+func (c *Cipher) DecodeNaturalTranscript(transcript string) ([]byte, error) {
+	words := transcriptWords(transcript)
+	var best []string
+	for _, name := range ThemeNames() {
+		sentences := segmentTranscript(words, c.themed(name))
+		if spokenLength(sentences) > spokenLength(best) {
+			best = sentences
+		}
+	}
+	if len(best) == 0 {
+		return nil, errors.New("no sentences found in transcript")
+	}
+	return c.DecodeNatural(strings.Join(best, " "))
+}
+
+// spokenLength counts the words of segmented sentences
+func spokenLength(sentences []string) int {
+	n := 0
+	for _, s := range sentences {
+		n += len(strings.Fields(s))
+	}
+	return n
+}
+
+// DecodeTranscript decodes a transcript using the default word lists (package-level)
+func DecodeTranscript(transcript string) ([]byte, error) {
+	return NewDefaultCipher().DecodeTranscript(transcript)
+}
+
+// DecodeNaturalTranscript decodes a natural transcript using the default word lists (package-level)
+func DecodeNaturalTranscript(transcript string) ([]byte, error) {
+	return NewDefaultCipher().DecodeNaturalTranscript(transcript)
+}
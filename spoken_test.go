@@ -0,0 +1,126 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// transcribe turns encoded text into what speech-to-text returns: no
+// punctuation, no capitals, hyphenated words split in two
+func transcribe(text string) string {
+	text = strings.ToLower(text)
+	text = strings.NewReplacer(".", " ", ",", " ", ":", " ", "-", " ", "\n", " ").Replace(text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func TestDecodeTranscript(t *testing.T) {
+	cipher, _ := NewCipher("spoken-key")
+	// Long enough to cover 1-, 2- and 3-byte sentences across several lengths
+	for _, input := range []string{"a", "hi", "Read this aloud on the call.", strings.Repeat("spoken word ", 20)} {
+		encoded, err := cipher.Encode([]byte(input))
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		transcript := "okay here it is " + transcribe(encoded) + " that's all thanks"
+		decoded, err := cipher.DecodeTranscript(transcript)
+		if err != nil {
+			t.Fatalf("DecodeTranscript(%q) failed: %v\n%s", input, err, transcript)
+		}
+		if !bytes.Equal(decoded, []byte(input)) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+	}
+}
+
+func TestDecodeNaturalTranscript(t *testing.T) {
+	cipher, _ := NewCipher("spoken-key")
+	input := []byte("A natural email read out on a voice call")
+
+	for i := 0; i < 6; i++ {
+		email, err := cipher.EncodeNatural(input)
+		if err != nil {
+			t.Fatalf("EncodeNatural failed: %v", err)
+		}
+		decoded, err := cipher.DecodeNaturalTranscript(transcribe(email))
+		if err != nil {
+			t.Fatalf("DecodeNaturalTranscript failed: %v\n%s", err, transcribe(email))
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+	}
+}
+
+func TestDecodeTranscriptSpellingVariants(t *testing.T) {
+	// "john" and "double-checks" as a recognizer may write them
+	encoded := "John double-checks Mary reports."
+	want, err := NewDefaultCipher().decodeRaw(encoded)
+	if err != nil {
+		t.Fatalf("decodeRaw failed: %v", err)
+	}
+	sentences := segmentTranscript(transcriptWords("Jon double checks Mary reports"), NewDefaultCipher())
+	got, err := NewDefaultCipher().decodeRaw(strings.Join(sentences, " "))
+	if err != nil {
+		t.Fatalf("decodeRaw failed: %v (%q)", err, sentences)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("decoded %v, want %v", got, want)
+	}
+}
+
+func TestSpokenVocabulary(t *testing.T) {
+	cipher, _ := NewCipher("spoken-key")
+	cipher.SetSpokenVocabulary(true)
+
+	for _, name := range ThemeNames() {
+		themed := cipher.themed(name)
+		for _, list := range [][]string{themed.names, themed.verbs, themed.objects} {
+			seen := make(map[string]bool)
+			for _, w := range list {
+				if seen[w] {
+					t.Errorf("theme %s: duplicate word %q", name, w)
+				}
+				seen[w] = true
+				if _, ok := spokenReplacements[w]; ok {
+					t.Errorf("theme %s: %q was not replaced", name, w)
+				}
+			}
+		}
+	}
+
+	input := []byte("Homophone-safe words for the phone")
+	for _, natural := range []bool{false, true} {
+		var encoded string
+		var decoded []byte
+		var err error
+		if natural {
+			encoded, _ = cipher.EncodeNatural(input)
+			decoded, err = cipher.DecodeNaturalTranscript(transcribe(encoded))
+		} else {
+			encoded, _ = cipher.Encode(input)
+			decoded, err = cipher.DecodeTranscript(transcribe(encoded))
+		}
+		if err != nil {
+			t.Fatalf("natural=%v: %v", natural, err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("natural=%v: decoded %q, want %q", natural, decoded, input)
+		}
+	}
+
+	// Switching back restores the original lists
+	cipher.SetSpokenVocabulary(false)
+	plain, _ := NewCipher("spoken-key")
+	for i := range plain.names {
+		if cipher.names[i] != plain.names[i] || cipher.verbs[i] != plain.verbs[i] || cipher.objects[i] != plain.objects[i] {
+			t.Fatalf("word %d differs after SetSpokenVocabulary(false)", i)
+		}
+	}
+}
+
+func TestDecodeTranscriptEmpty(t *testing.T) {
+	if _, err := DecodeTranscript("hello there nothing to see"); err == nil {
+		t.Error("expected an error for a transcript without sentences")
+	}
+}
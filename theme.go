@@ -0,0 +1,375 @@
+package sentencecipher
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Theme is a vocabulary for natural and Markdown modes. Verbs and objects
+// are 256 distinct words each. Names are shared by all themes unless a theme
+// brings its own 256, and openers and closers default to the built-in email
+// greetings and sign-offs.
+type Theme struct {
+	Name     string   `json:"name"`
+	Subjects []string `json:"subjects"`
+	Verbs    []string `json:"verbs"`
+	Objects  []string `json:"objects"`
+	Names    []string `json:"names,omitempty"`
+	Openers  []string `json:"openers,omitempty"`
+	Closers  []string `json:"closers,omitempty"`
+}
+
+// ThemeMatch is the theme detected for a natural-mode message
+type ThemeMatch struct {
+	Theme string
+	// Confidence is the share of body verbs and objects that point to Theme
+	// rather than the runner-up (0 = no evidence, 1 = unambiguous)
+	Confidence float64
+}
+
+type registeredTheme struct {
+	Theme
+	verbSet   map[string]bool
+	objectSet map[string]bool
+}
+
+var themes = struct {
+	sync.RWMutex
+	byName map[string]*registeredTheme
+	order  []string
+}{byName: make(map[string]*registeredTheme)}
+
+func init() {
+	mustRegisterTheme(Theme{Name: "business", Subjects: businessSubjects, Verbs: defaultVerbs, Objects: defaultObjects})
+	mustRegisterTheme(Theme{Name: "tech", Subjects: techSubjects, Verbs: techVerbs, Objects: techObjects})
+}
+
+func mustRegisterTheme(t Theme) {
+	if err := RegisterTheme(t); err != nil {
+		panic(err)
+	}
+}
+
+// RegisterTheme adds a theme (or replaces one with the same name) so that
+// decoding considers it
+func RegisterTheme(t Theme) error {
+	if err := validateTheme(t); err != nil {
+		return err
+	}
+	rt := &registeredTheme{
+		Theme: Theme{
+			Name: t.Name, Subjects: copySlice(t.Subjects), Verbs: copySlice(t.Verbs), Objects: copySlice(t.Objects),
+			Names: copySlice(t.Names), Openers: copySlice(t.Openers), Closers: copySlice(t.Closers),
+		},
+		verbSet:   wordSet(t.Verbs),
+		objectSet: wordSet(t.Objects),
+	}
+
+	themes.Lock()
+	defer themes.Unlock()
+	if _, ok := themes.byName[t.Name]; !ok {
+		themes.order = append(themes.order, t.Name)
+	}
+	themes.byName[t.Name] = rt
+	return nil
+}
+
+// LoadTheme reads a theme from a JSON file (see Theme) and registers it
+func LoadTheme(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("reading theme: %w", err)
+	}
+	var t Theme
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Theme{}, fmt.Errorf("parsing theme %s: %w", path, err)
+	}
+	if err := RegisterTheme(t); err != nil {
+		return Theme{}, err
+	}
+	return t, nil
+}
+
+// SetTheme makes EncodeNatural and EncodeMarkdown write with a registered
+// theme instead of picking business or tech per message ("" restores that)
+func (c *Cipher) SetTheme(name string) error {
+	if _, ok := lookupTheme(name); name != "" && !ok {
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	c.theme = name
+	return nil
+}
+
+// coverTheme returns the theme of a message with the given envelope seed
+func (c *Cipher) coverTheme(seed int) string {
+	if c.theme != "" {
+		return c.theme
+	}
+	return themeForSeed(seed)
+}
+
+// themed returns the cipher for theme with this cipher's key and vocabulary
+func (c *Cipher) themed(theme string) *Cipher {
+	themed := NewThemedCipher(c.key, theme)
+	themed.SetSpokenVocabulary(c.spoken)
+	themed.patterns = c.patterns
+	return themed
+}
+
+// themeEnvelope returns the subject, opener and closer of theme for seed
+func themeEnvelope(theme string, seed int) (subject, opener, closer string) {
+	rt, ok := lookupTheme(theme)
+	if !ok {
+		rt, _ = lookupTheme("business")
+	}
+	subjects, openers, closers := rt.Subjects, rt.Openers, rt.Closers
+	if len(subjects) == 0 {
+		subjects = businessSubjects
+	}
+	if len(openers) == 0 {
+		openers = emailOpeners
+	}
+	if len(closers) == 0 {
+		closers = emailClosers
+	}
+	return subjects[seed%len(subjects)], openers[seed%len(openers)], closers[seed%len(closers)]
+}
+
+// LookupTheme returns a registered theme
+func LookupTheme(name string) (Theme, bool) {
+	rt, ok := lookupTheme(name)
+	if !ok {
+		return Theme{}, false
+	}
+	return rt.Theme, true
+}
+
+// ThemeNames returns the registered theme names in registration order
+func ThemeNames() []string {
+	themes.RLock()
+	defer themes.RUnlock()
+	return copySlice(themes.order)
+}
+
+// unregisterTheme removes a registered theme (tests clean up with it)
+func unregisterTheme(name string) {
+	themes.Lock()
+	defer themes.Unlock()
+	if _, ok := themes.byName[name]; !ok {
+		return
+	}
+	delete(themes.byName, name)
+	for i, n := range themes.order {
+		if n == name {
+			themes.order = append(themes.order[:i:i], themes.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func lookupTheme(name string) (*registeredTheme, bool) {
+	themes.RLock()
+	defer themes.RUnlock()
+	rt, ok := themes.byName[name]
+	return rt, ok
+}
+
+func validateTheme(t Theme) error {
+	if t.Name == "" {
+		return errors.New("theme name is required")
+	}
+	for _, list := range []struct {
+		kind  string
+		words []string
+	}{{"verbs", t.Verbs}, {"objects", t.Objects}} {
+		if len(list.words) != 256 {
+			return fmt.Errorf("theme %s: %d %s, want 256", t.Name, len(list.words), list.kind)
+		}
+		seen := make(map[string]bool, 256)
+		for _, w := range list.words {
+			if w == "" || w != strings.ToLower(w) || strings.ContainsAny(w, " \t\n.,") {
+				return fmt.Errorf("theme %s: invalid word %q in %s", t.Name, w, list.kind)
+			}
+			if seen[w] {
+				return fmt.Errorf("theme %s: duplicate word %q in %s", t.Name, w, list.kind)
+			}
+			if isPatternLiteral(w) {
+				return fmt.Errorf("theme %s: word %q in %s is a sentence pattern word", t.Name, w, list.kind)
+			}
+			seen[w] = true
+		}
+	}
+	if v, ok := checkVerbForms(t.Verbs); !ok {
+		return fmt.Errorf("theme %s: verb %q shares its base form or participle with another verb", t.Name, v)
+	}
+	if err := validateThemeNames(t); err != nil {
+		return err
+	}
+	for _, line := range append(copySlice(t.Openers), t.Closers...) {
+		if line = strings.TrimSpace(line); line == "" || strings.HasSuffix(line, ".") || strings.HasSuffix(line, "?") {
+			return fmt.Errorf("theme %s: opener or closer %q must not be empty or end a sentence", t.Name, line)
+		}
+	}
+	return nil
+}
+
+// validateThemeNames checks a theme's own names, which must not be its verbs or objects either
+func validateThemeNames(t Theme) error {
+	if len(t.Names) == 0 {
+		return nil
+	}
+	if len(t.Names) != 256 {
+		return fmt.Errorf("theme %s: %d names, want 256", t.Name, len(t.Names))
+	}
+	taken := wordSet(append(copySlice(t.Verbs), t.Objects...))
+	seen := make(map[string]bool, 256)
+	for _, w := range t.Names {
+		switch {
+		case w == "" || w != strings.ToLower(w) || strings.ContainsAny(w, " \t\n.,"):
+			return fmt.Errorf("theme %s: invalid word %q in names", t.Name, w)
+		case seen[w]:
+			return fmt.Errorf("theme %s: duplicate word %q in names", t.Name, w)
+		case isPatternLiteral(w):
+			return fmt.Errorf("theme %s: word %q in names is a sentence pattern word", t.Name, w)
+		case taken[w]:
+			return fmt.Errorf("theme %s: name %q is also a verb or object", t.Name, w)
+		}
+		seen[w] = true
+	}
+	return nil
+}
+
+func wordSet(words []string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// themeScore is the evidence for one theme
+type themeScore struct {
+	name    string
+	hits    int // body verbs and objects found in the theme's lists
+	votes   int // indirect-object parity votes (see envelope.go)
+	subject bool
+	order   int
+}
+
+type rankedThemes struct {
+	scores []themeScore
+	slots  int // verb and object positions in the body
+}
+
+// rankThemes orders the registered themes from most to least likely for the
+// body sentences: vocabulary hits first, then parity votes, then the subject
+func (c *Cipher) rankThemes(sentences []string, subjectTheme string) rankedThemes {
+	// A sentence scores its verb and object slots for every theme whose
+	// lists parse it
+	var themed []*Cipher
+	for _, name := range ThemeNames() {
+		themed = append(themed, c.themed(name))
+	}
+	parsed := make([][]int, len(themed))
+	slots := 0
+	for _, sentence := range sentences {
+		words := naturalWords(sentence)
+		most := 0
+		for i, t := range themed {
+			n := 0
+			if p, ok := t.parseSentence(words); ok {
+				n = p.size - 1 // no verb or object in a 1-byte sentence
+			}
+			parsed[i] = append(parsed[i], n)
+			if n > most {
+				most = n
+			}
+		}
+		slots += most
+	}
+
+	var ranked rankedThemes
+	ranked.slots = slots
+	for i, name := range ThemeNames() {
+		score := themeScore{name: name, subject: name == subjectTheme, order: i}
+		for _, n := range parsed[i] {
+			score.hits += n
+		}
+		score.votes = c.themeVotes(name, sentences)
+		ranked.scores = append(ranked.scores, score)
+	}
+
+	sort.SliceStable(ranked.scores, func(i, j int) bool {
+		a, b := ranked.scores[i], ranked.scores[j]
+		if a.hits != b.hits {
+			return a.hits > b.hits
+		}
+		if a.votes != b.votes {
+			return a.votes > b.votes
+		}
+		if a.subject != b.subject {
+			return a.subject
+		}
+		return a.order < b.order
+	})
+	return ranked
+}
+
+// match builds the ThemeMatch for the theme that decoded the message
+func (r rankedThemes) match(name string) ThemeMatch {
+	m := ThemeMatch{Theme: name}
+	if r.slots == 0 {
+		return m
+	}
+	hits, runnerUp := 0, 0
+	for _, s := range r.scores {
+		if s.name == name {
+			hits = s.hits
+		} else if s.hits > runnerUp {
+			runnerUp = s.hits
+		}
+	}
+	if hits > runnerUp {
+		m.Confidence = float64(hits-runnerUp) / float64(r.slots)
+	}
+	return m
+}
+
+// DetectTheme reports the theme of a natural email: registered themes are
+// scored on the body's verbs and objects, then tried in that order until one
+// decodes the body (an empty Theme means none did)
+func (c *Cipher) DetectTheme(encoded string) ThemeMatch {
+	_, match, err := c.decodeNaturalTheme(encoded)
+	if err != nil {
+		return ThemeMatch{}
+	}
+	return match
+}
+
+// DecodeNaturalTheme is DecodeNatural that also reports the detected theme
+func (c *Cipher) DecodeNaturalTheme(encoded string) ([]byte, ThemeMatch, error) {
+	compressed, match, err := c.decodeNaturalTheme(encoded)
+	if err != nil {
+		return nil, match, err
+	}
+	data, err := c.openPayload(compressed)
+	return data, match, err
+}
+
+// themeBit is the bit each data sentence's indirect object votes for
+func themeBit(theme string) int {
+	switch theme {
+	case "business":
+		return 0
+	case "tech":
+		return 1
+	}
+	sum := sha256.Sum256([]byte(theme))
+	return int(sum[0] & 1)
+}
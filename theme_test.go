@@ -0,0 +1,102 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bodyOnly drops the subject, opener, closer and signature of a natural email
+func bodyOnly(email string) string {
+	var body []string
+	for _, line := range strings.Split(email, "\n") {
+		if strings.HasSuffix(strings.TrimSpace(line), ".") {
+			body = append(body, line)
+		}
+	}
+	return strings.Join(body, "\n")
+}
+
+func TestDetectThemeWithoutSubject(t *testing.T) {
+	cipher, _ := NewCipher("detect-key")
+	input := []byte("A forwarded body without any headers at all, which used to fail for tech")
+
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		email, _ := cipher.EncodeNatural(input)
+		want := themeForSubject(strings.TrimPrefix(strings.SplitN(email, "\n", 2)[0], "Subject: "))
+
+		decoded, match, err := cipher.DecodeNaturalTheme(bodyOnly(email))
+		if err != nil {
+			t.Fatalf("DecodeNaturalTheme error: %v", err)
+		}
+		if !bytes.Equal(decoded, input) {
+			t.Errorf("decoded %q, want %q", decoded, input)
+		}
+		if match.Theme != want {
+			t.Errorf("detected %q, want %q", match.Theme, want)
+		}
+		if match.Confidence < 0.3 || match.Confidence > 1 {
+			t.Errorf("confidence %.2f for a %d-byte message", match.Confidence, len(input))
+		}
+		seen[match.Theme] = true
+	}
+	if !seen["tech"] {
+		t.Error("no tech-themed message was produced")
+	}
+}
+
+func testTheme(name string) Theme {
+	theme := Theme{Name: name, Subjects: []string{"Garden Party"}}
+	for i := 0; i < 256; i++ {
+		theme.Verbs = append(theme.Verbs, fmt.Sprintf("%sverb%d", name, i))
+		theme.Objects = append(theme.Objects, fmt.Sprintf("%sobject%d", name, i))
+	}
+	return theme
+}
+
+func TestRegisteredThemeIsDetected(t *testing.T) {
+	if err := RegisterTheme(testTheme("garden")); err != nil {
+		t.Fatalf("RegisterTheme error: %v", err)
+	}
+	t.Cleanup(func() { unregisterTheme("garden") })
+	if _, ok := LookupTheme("garden"); !ok {
+		t.Fatal("LookupTheme did not find the registered theme")
+	}
+
+	input := []byte("grown in the garden")
+	compressed, _ := compress(input)
+	body := NewThemedCipher("", "garden").encodeRaw(compressed)
+
+	cipher := NewDefaultCipher()
+	match := cipher.DetectTheme(body)
+	if match.Theme != "garden" || match.Confidence != 1 {
+		t.Errorf("DetectTheme = %+v, want garden with confidence 1", match)
+	}
+	decoded, err := cipher.DecodeNatural(body)
+	if err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeNatural = %q, %v", decoded, err)
+	}
+}
+
+func TestRegisterThemeValidation(t *testing.T) {
+	bad := testTheme("broken")
+	bad.Verbs[10] = bad.Verbs[11]
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for duplicate verbs")
+	}
+	bad = testTheme("broken")
+	bad.Objects = bad.Objects[:255]
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for short object list")
+	}
+	bad = testTheme("broken")
+	bad.Verbs[0] = "Two Words"
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for invalid word")
+	}
+	if _, ok := LookupTheme("broken"); ok {
+		t.Error("invalid theme was registered")
+	}
+}
@@ -0,0 +1,427 @@
+package sentencecipher
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BuildTheme derives a theme from a corpus of the team's own writing (mail,
+// chat logs, notes) with a few morphological heuristics:
+//   - names are capitalized words that never appear in lowercase and do not
+//     start a sentence, plus signature lines of one or two such words
+//   - verbs are base forms after "to", modals and plural pronouns ("we
+//     review"), or "-s" forms after "he", "she", "it" and names, all turned
+//     into the third person ("reviews")
+//   - objects are the last word of a noun phrase after a determiner ("the
+//     budget", "our release notes"), turned into the plural
+//   - subjects are recurring "Subject:" and "# " title lines, openers and
+//     closers recurring short lines ending in a comma; a closer is followed by
+//     a signature or contains a sign-off word
+//
+// The most frequent words that fit the sentence patterns fill each list;
+// whatever the corpus cannot supply comes from the built-in lists.
+// The result passes the same checks as RegisterTheme.
+
+// ThemeBuildStats counts the entries of a built theme that come from the corpus
+type ThemeBuildStats struct {
+	Names, Verbs, Objects      int
+	Subjects, Openers, Closers int
+}
+
+// minRecurring is how often a line must appear to become a subject, opener or closer
+const minRecurring = 2
+
+// maxEnvelopeLines caps the subjects, openers and closers taken from a corpus
+const maxEnvelopeLines = 16
+
+// themeStopwords are function words that are never names, verbs or objects
+var themeStopwords = wordSet(strings.Fields(`
+	a about above after again against all also am an and any are as at be
+	because been before being below between both but by can could did do does
+	doing down during each either else even ever every few for from further get
+	gets got had has have having he her here hers him his how however if in
+	into is it its itself just least less let lets like many may me might more
+	most much must my neither no nor not now of off on once one only or other
+	our ours out over own per please quite rather same she should since so some
+	still such than that the their theirs them then there these they this those
+	though through thus to too under until up upon us very via was we well were
+	what when where whether which while who whom whose why will with within
+	without would yes yet you your yours
+	hi hello hey dear thanks thank regards best cheers sincerely
+	monday tuesday wednesday thursday friday saturday sunday
+	january february march april june july august september october november december
+`))
+
+// verbCues precede a base-form verb ("to review", "we review")
+var verbCues = wordSet(strings.Fields("to will would can could should must might may shall we they you i lets"))
+
+// thirdPersonCues precede a third-person verb ("she reviews")
+var thirdPersonCues = wordSet(strings.Fields("he she it"))
+
+// determiners start a noun phrase
+var determiners = wordSet(strings.Fields("the a an our your their my his her its this that these those every each some any all"))
+
+// signOffWords mark a closer
+var signOffWords = wordSet(strings.Fields("regards thanks thank best cheers sincerely soon wishes care later"))
+
+// corpusWord is a word of the corpus with its original spelling
+type corpusWord struct {
+	text, lower string
+	start       bool // first word of a sentence or line
+}
+
+// BuildTheme builds the theme name from corpus (see above)
+func BuildTheme(name, corpus string) (Theme, ThemeBuildStats, error) {
+	var stats ThemeBuildStats
+	lines := strings.Split(normalizeText(corpus), "\n")
+
+	var sentences [][]corpusWord
+	lowercase := map[string]bool{}
+	for _, line := range lines {
+		for _, s := range corpusSentences(line) {
+			for _, w := range s {
+				if w.text == w.lower {
+					lowercase[w.lower] = true
+				}
+			}
+			sentences = append(sentences, s)
+		}
+	}
+	if len(sentences) == 0 {
+		return Theme{}, stats, errors.New("corpus has no words")
+	}
+
+	nameCounts := map[string]int{}
+	for _, s := range sentences {
+		signature := len(s) <= 2
+		for _, w := range s {
+			if !isCapitalized(w.text) || lowercase[w.lower] || themeStopwords[w.lower] || len(w.lower) < 3 {
+				signature = false
+				continue
+			}
+			if !w.start {
+				nameCounts[w.lower]++
+			}
+		}
+		if signature {
+			for _, w := range s {
+				nameCounts[w.lower]++
+			}
+		}
+	}
+
+	verbCounts, objectCounts := map[string]int{}, map[string]int{}
+	for _, s := range sentences {
+		for i := 1; i < len(s); i++ {
+			w, prev := s[i].lower, s[i-1].lower
+			if !isContentWord(w) || nameCounts[w] > 0 {
+				continue
+			}
+			switch {
+			case verbCues[prev] && !strings.HasSuffix(w, "ing") && !strings.HasSuffix(w, "ed"):
+				if v := thirdPerson(w); verbBaseForm(v) == w {
+					verbCounts[v]++
+				}
+			case (thirdPersonCues[prev] || nameCounts[prev] > 0) && isThirdPerson(w):
+				verbCounts[w]++
+			}
+		}
+	}
+
+	// Noun phrases stop before a name or a known verb ("the team reviews")
+	for _, s := range sentences {
+		for i := 1; i < len(s); i++ {
+			if !determiners[s[i-1].lower] {
+				continue
+			}
+			head := i
+			for head+1 < len(s) && head-i < 2 && isNounWord(s[head+1].lower) && nameCounts[s[head+1].lower] == 0 &&
+				verbCounts[s[head+1].lower] == 0 && verbCounts[thirdPerson(s[head+1].lower)] == 0 {
+				head++
+			}
+			if w := s[head].lower; isNounWord(w) && nameCounts[w] == 0 {
+				objectCounts[plural(w)]++
+			}
+		}
+	}
+
+	// A word is a verb or an object, whichever the corpus uses it as more often
+	for w, n := range verbCounts {
+		if objectCounts[w] > n {
+			delete(verbCounts, w)
+		} else {
+			delete(objectCounts, w)
+		}
+	}
+
+	// Corpus words first, so the built-in lists only fill the gaps
+	taken := map[string]bool{}
+	verbs, objects, names := &themeList{forms: newVerbFormSet()}, &themeList{}, &themeList{}
+	stats.Verbs = verbs.addAll(rankWords(verbCounts), taken)
+	stats.Objects = objects.addAll(rankWords(objectCounts), taken)
+	stats.Names = names.addAll(rankWords(nameCounts), taken)
+	verbs.addAll(append(copySlice(defaultVerbs), techVerbs...), taken)
+	objects.addAll(append(copySlice(defaultObjects), techObjects...), taken)
+	if stats.Names > 0 {
+		names.addAll(defaultNames, taken)
+	}
+	t := Theme{Name: name, Verbs: verbs.words, Objects: objects.words, Names: names.words}
+
+	t.Subjects, t.Openers, t.Closers = envelopeLines(lines)
+	stats.Subjects, stats.Openers, stats.Closers = len(t.Subjects), len(t.Openers), len(t.Closers)
+	if len(t.Subjects) == 0 {
+		t.Subjects = copySlice(businessSubjects)
+	}
+
+	if err := validateTheme(t); err != nil {
+		return Theme{}, stats, err
+	}
+	return t, stats, nil
+}
+
+// corpusSentences splits a line into sentences of words, dropping punctuation and numbers
+func corpusSentences(line string) [][]corpusWord {
+	line = strings.TrimSpace(line)
+	if lower := strings.ToLower(line); strings.HasPrefix(lower, "subject:") || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	var sentences [][]corpusWord
+	var current []corpusWord
+	for _, field := range strings.Fields(line) {
+		word := strings.TrimFunc(field, func(r rune) bool { return !unicode.IsLetter(r) })
+		if word != "" && isLetters(word) {
+			current = append(current, corpusWord{text: word, lower: strings.ToLower(word), start: len(current) == 0})
+		}
+		if strings.ContainsAny(field, ".?!:;") && len(current) > 0 {
+			sentences = append(sentences, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		sentences = append(sentences, current)
+	}
+	return sentences
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			if r < 'A' || r > 'Z' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isCapitalized(s string) bool {
+	return s[0] >= 'A' && s[0] <= 'Z' && strings.ToLower(s[1:]) == s[1:]
+}
+
+// isContentWord reports whether a lowercase word may be a verb or an object
+func isContentWord(w string) bool {
+	return len(w) >= 3 && !themeStopwords[w] && !strings.HasSuffix(w, "ly")
+}
+
+// isNounWord reports whether a lowercase word may be the head of a noun phrase
+func isNounWord(w string) bool {
+	return isContentWord(w) && !isFillerWord(w) && !strings.HasSuffix(w, "ed")
+}
+
+// isThirdPerson reports whether w looks like a third-person verb ("reviews", not "process")
+func isThirdPerson(w string) bool {
+	return strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") &&
+		!strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is")
+}
+
+// thirdPerson inflects a base form ("review" -> "reviews", "clarify" -> "clarifies")
+func thirdPerson(base string) string {
+	n := len(base)
+	switch {
+	case n > 1 && base[n-1] == 'y' && !isVowel(base[n-2]):
+		return base[:n-1] + "ies"
+	case strings.HasSuffix(base, "s"), strings.HasSuffix(base, "x"), strings.HasSuffix(base, "z"),
+		strings.HasSuffix(base, "ch"), strings.HasSuffix(base, "sh"), strings.HasSuffix(base, "o"):
+		return base + "es"
+	}
+	return base + "s"
+}
+
+// plural returns the plural of a noun, keeping words that already look plural
+func plural(noun string) string {
+	switch {
+	case isThirdPerson(noun):
+		return noun
+	case strings.HasSuffix(noun, "o"):
+		return noun + "s"
+	}
+	return thirdPerson(noun)
+}
+
+// verbFormSet tracks the base forms and participles of the verbs picked so far
+type verbFormSet struct {
+	bases, participles map[string]bool
+}
+
+func newVerbFormSet() *verbFormSet {
+	return &verbFormSet{bases: map[string]bool{}, participles: map[string]bool{}}
+}
+
+// add records the forms of verb unless another verb already has one of them
+func (f *verbFormSet) add(verb string) bool {
+	b, p := verbBaseForm(verb), verbParticiple(verb)
+	if f.bases[b] || f.participles[p] {
+		return false
+	}
+	f.bases[b], f.participles[p] = true, true
+	return true
+}
+
+// themeList is a word list of a theme being built
+type themeList struct {
+	words []string
+	forms *verbFormSet // for verbs
+}
+
+// addAll appends the usable words of candidates, up to 256, and returns how many it took
+func (l *themeList) addAll(candidates []string, taken map[string]bool) int {
+	n := 0
+	for _, w := range candidates {
+		if len(l.words) == 256 {
+			break
+		}
+		if taken[w] || isPatternLiteral(w) || isFillerWord(w) || (l.forms != nil && !l.forms.add(w)) {
+			continue
+		}
+		taken[w] = true
+		l.words = append(l.words, w)
+		n++
+	}
+	return n
+}
+
+// rankWords returns the words of counts, most frequent first
+func rankWords(counts map[string]int) []string {
+	ranked := make([]string, 0, len(counts))
+	for w := range counts {
+		ranked = append(ranked, w)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] != counts[ranked[j]] {
+			return counts[ranked[i]] > counts[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	return ranked
+}
+
+// isFillerWord reports whether w is a word of a filler ending (see fillers.go)
+func isFillerWord(w string) bool {
+	for _, ending := range append(copySlice(workEndings), dailyEndings...) {
+		for _, f := range strings.Fields(ending) {
+			if f == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// envelopeLines returns the recurring subjects, openers and closers of a corpus
+func envelopeLines(lines []string) (subjects, openers, closers []string) {
+	subjectCounts, commaCounts, closerVotes := map[string]int{}, map[string]int{}, map[string]int{}
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(strings.ToLower(line), "subject:"):
+			subj := strings.TrimSpace(line[len("subject:"):])
+			for {
+				lower := strings.ToLower(subj)
+				if !strings.HasPrefix(lower, "re:") && !strings.HasPrefix(lower, "fw:") && !strings.HasPrefix(lower, "fwd:") {
+					break
+				}
+				subj = strings.TrimSpace(subj[strings.Index(subj, ":")+1:])
+			}
+			if subj = trimSubjectCounter(subj); subj != "" {
+				subjectCounts[subj]++
+			}
+		case strings.HasPrefix(line, "# "):
+			subjectCounts[strings.TrimSpace(line[2:])]++
+		case strings.HasSuffix(line, ",") && len(strings.Fields(line)) <= 4 && !strings.ContainsAny(line, "0123456789.?"):
+			commaCounts[line]++
+			if isSignOff(line) || isSignature(nextLine(lines, i)) {
+				closerVotes[line]++
+			} else {
+				closerVotes[line]--
+			}
+		}
+	}
+
+	for _, line := range recurringLines(commaCounts) {
+		if closerVotes[line] > 0 {
+			closers = append(closers, line)
+		} else {
+			openers = append(openers, line)
+		}
+	}
+	return capLines(recurringLines(subjectCounts)), capLines(openers), capLines(closers)
+}
+
+// recurringLines returns the lines of counts seen at least minRecurring times, most frequent first
+func recurringLines(counts map[string]int) []string {
+	var lines []string
+	for line, n := range counts {
+		if n >= minRecurring {
+			lines = append(lines, line)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if counts[lines[i]] != counts[lines[j]] {
+			return counts[lines[i]] > counts[lines[j]]
+		}
+		return lines[i] < lines[j]
+	})
+	return lines
+}
+
+func capLines(lines []string) []string {
+	if len(lines) > maxEnvelopeLines {
+		return lines[:maxEnvelopeLines]
+	}
+	return lines
+}
+
+// nextLine returns the first non-empty line after lines[i]
+func nextLine(lines []string, i int) string {
+	for _, line := range lines[i+1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+func isSignOff(line string) bool {
+	for _, w := range strings.Fields(strings.ToLower(strings.TrimSuffix(line, ","))) {
+		if signOffWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSignature reports whether a line is just one or two capitalized words
+func isSignature(line string) bool {
+	words := strings.Fields(line)
+	if len(words) == 0 || len(words) > 2 {
+		return false
+	}
+	for _, w := range words {
+		if !isLetters(w) || !isCapitalized(w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package sentencecipher
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// opsCorpus is a few emails of a hypothetical operations team
+const opsCorpus = `Subject: Release planning
+Hi folks,
+
+We should deploy the billing service on Tuesday. Priya reviews the migration scripts and Omar tests the rollback plan. Can you triage the open tickets before standup? I will merge the hotfix after Priya approves the pull request.
+
+Cheers,
+Omar
+
+Subject: Re: Release planning
+Hi folks,
+
+Priya deploys the billing service tomorrow. We need to rotate the credentials and archive the old buckets. Please label the tickets so we can prioritize the backlog.
+
+Cheers,
+Priya
+
+Subject: Incident review
+Morning team,
+
+The outage started when the cache evicted the session keys. Omar rolls back the config and Priya monitors the dashboards. We will document the timeline and schedule the retro.
+
+Thanks all,
+Omar
+
+Subject: Incident review
+Morning team,
+
+Let us triage the remaining alerts. Priya escalates the vendor ticket and Omar rebuilds the images.
+
+Thanks all,
+Priya
+`
+
+func TestBuildTheme(t *testing.T) {
+	theme, stats, err := BuildTheme("ops", opsCorpus)
+	if err != nil {
+		t.Fatalf("BuildTheme error: %v", err)
+	}
+	for _, list := range []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{"verbs", theme.Verbs, []string{"deploys", "reviews", "triages", "rotates", "escalates"}},
+		{"objects", theme.Objects, []string{"tickets", "credentials", "dashboards", "keys"}},
+		{"names", theme.Names, []string{"priya", "omar"}},
+		{"subjects", theme.Subjects, []string{"Release planning", "Incident review"}},
+		{"openers", theme.Openers, []string{"Hi folks,", "Morning team,"}},
+		{"closers", theme.Closers, []string{"Cheers,", "Thanks all,"}},
+	} {
+		for _, w := range list.want {
+			if findIndex(list.words, w) == -1 {
+				t.Errorf("%s: %q missing from %v", list.name, w, list.words)
+			}
+		}
+	}
+	if stats.Names != 2 || stats.Verbs < 10 || stats.Openers != 2 {
+		t.Errorf("stats = %+v", stats)
+	}
+	for _, w := range []string{"tomorrow", "tuesday", "the", "evicteds"} {
+		if findIndex(theme.Objects, w) != -1 || findIndex(theme.Verbs, w) != -1 {
+			t.Errorf("%q was picked", w)
+		}
+	}
+
+	if _, _, err := BuildTheme("empty", "  \n1234\n"); err == nil {
+		t.Error("expected error for a corpus without words")
+	}
+}
+
+func TestBuiltThemeEncodes(t *testing.T) {
+	theme, _, err := BuildTheme("ops-built", opsCorpus)
+	if err != nil {
+		t.Fatalf("BuildTheme error: %v", err)
+	}
+	data, _ := json.MarshalIndent(theme, "", "  ")
+	path := filepath.Join(t.TempDir(), "ops.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTheme(path); err != nil {
+		t.Fatalf("LoadTheme error: %v", err)
+	}
+	t.Cleanup(func() { unregisterTheme("ops-built") })
+
+	sender, _ := NewCipher("theme-key")
+	if err := sender.SetTheme("ops-built"); err != nil {
+		t.Fatalf("SetTheme error: %v", err)
+	}
+	input := []byte("Written in the team's own words")
+	email, err := sender.EncodeNatural(input)
+	if err != nil {
+		t.Fatalf("EncodeNatural error: %v", err)
+	}
+	if !strings.Contains(email, "Hi folks,") && !strings.Contains(email, "Morning team,") {
+		t.Errorf("theme opener unused:\n%s", email)
+	}
+
+	receiver, _ := NewCipher("theme-key")
+	decoded, match, err := receiver.DecodeNaturalTheme(email)
+	if err != nil || !bytes.Equal(decoded, input) || match.Theme != "ops-built" {
+		t.Errorf("DecodeNaturalTheme = %q, %+v, %v\n%s", decoded, match, err, email)
+	}
+
+	notes, _ := sender.EncodeMarkdown(input)
+	if decoded, err := receiver.DecodeMarkdown(notes); err != nil || !bytes.Equal(decoded, input) {
+		t.Errorf("DecodeMarkdown = %q, %v\n%s", decoded, err, notes)
+	}
+
+	if err := sender.SetTheme("no-such-theme"); err == nil {
+		t.Error("expected error for an unknown theme")
+	}
+}
+
+func TestThemeValidationOfNamesAndEnvelope(t *testing.T) {
+	bad := testTheme("bad-names")
+	bad.Names = copySlice(defaultNames)
+	bad.Names[0] = bad.Verbs[0]
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for a name that is also a verb")
+	}
+	bad = testTheme("bad-opener")
+	bad.Openers = []string{"Morning."}
+	if err := RegisterTheme(bad); err == nil {
+		t.Error("expected error for an opener that ends a sentence")
+	}
+}
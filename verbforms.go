@@ -0,0 +1,103 @@
+package sentencecipher
+
+import "strings"
+
+// Verb lists hold third-person forms ("reviews"). Questions and coordinated
+// subjects need the base form ("review") and the passive voice the past
+// participle ("reviewed"); both are derived here and must be unique within a
+// list, which RegisterTheme checks.
+
+// irregularBaseForms covers verbs whose base form the suffix rules get wrong
+var irregularBaseForms = map[string]string{
+	"caches": "cache", "focuses": "focus",
+}
+
+// irregularParticiples maps base forms to irregular (or consonant-doubling)
+// past participles
+var irregularParticiples = map[string]string{
+	"bind": "bound", "begin": "begun", "break": "broken", "bring": "brought",
+	"broadcast": "broadcast", "build": "built", "catch": "caught", "cut": "cut",
+	"draw": "drawn", "find": "found", "get": "gotten", "give": "given",
+	"grow": "grown", "hide": "hidden", "hold": "held", "input": "input",
+	"keep": "kept", "lead": "led", "make": "made", "meet": "met",
+	"put": "put", "read": "read", "reset": "reset", "rewrite": "rewritten",
+	"run": "run", "send": "sent", "set": "set", "show": "shown",
+	"shrink": "shrunk", "shut": "shut", "teach": "taught", "tell": "told",
+	"throw": "thrown", "unbind": "unbound", "understand": "understood", "write": "written",
+	// Doubled consonants the syllable rule misses
+	"commit": "committed", "control": "controlled", "debug": "debugged", "emit": "emitted",
+	"equip": "equipped", "format": "formatted", "permit": "permitted", "program": "programmed",
+	"submit": "submitted", "transfer": "transferred",
+	// Nouns used as verbs
+	"checkout": "checked-out", "provider": "provided", "response": "responded",
+}
+
+// verbBaseForm turns a third-person verb into its base form ("clarifies" -> "clarify")
+func verbBaseForm(verb string) string {
+	if base, ok := irregularBaseForms[verb]; ok {
+		return base
+	}
+	switch {
+	case strings.HasSuffix(verb, "ies"):
+		return strings.TrimSuffix(verb, "ies") + "y"
+	case strings.HasSuffix(verb, "sses"), strings.HasSuffix(verb, "shes"),
+		strings.HasSuffix(verb, "ches"), strings.HasSuffix(verb, "xes"),
+		strings.HasSuffix(verb, "zzes"), strings.HasSuffix(verb, "oes"):
+		return strings.TrimSuffix(verb, "es")
+	default:
+		return strings.TrimSuffix(verb, "s")
+	}
+}
+
+// verbParticiple returns the past participle of a third-person verb ("reviews" -> "reviewed")
+func verbParticiple(verb string) string {
+	base := verbBaseForm(verb)
+	if p, ok := irregularParticiples[base]; ok {
+		return p
+	}
+	n := len(base)
+	switch {
+	case strings.HasSuffix(base, "e"):
+		return base + "d"
+	case n > 1 && base[n-1] == 'y' && !isVowel(base[n-2]):
+		return base[:n-1] + "ied"
+	case isShortSyllable(base):
+		return base + base[n-1:] + "ed"
+	default:
+		return base + "ed"
+	}
+}
+
+// isShortSyllable reports whether a base form is a single syllable ending in
+// one vowel and one consonant ("drop", "scan"), whose last letter doubles
+func isShortSyllable(base string) bool {
+	n := len(base)
+	if n < 3 || strings.ContainsAny(base[n-1:], "aeiouwxy") || !isVowel(base[n-2]) || isVowel(base[n-3]) {
+		return false
+	}
+	vowels := 0
+	for i := 0; i < n; i++ {
+		if isVowel(base[i]) && (i == 0 || !isVowel(base[i-1])) {
+			vowels++
+		}
+	}
+	return vowels == 1
+}
+
+func isVowel(b byte) bool {
+	return strings.IndexByte("aeiou", b) != -1
+}
+
+// checkVerbForms reports a verb whose base form or participle another verb shares
+func checkVerbForms(verbs []string) (string, bool) {
+	bases := make(map[string]bool, len(verbs))
+	participles := make(map[string]bool, len(verbs))
+	for _, v := range verbs {
+		b, p := verbBaseForm(v), verbParticiple(v)
+		if bases[b] || participles[p] {
+			return v, false
+		}
+		bases[b], participles[p] = true, true
+	}
+	return "", true
+}